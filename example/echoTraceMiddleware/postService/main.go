@@ -3,6 +3,7 @@ package main
 import (
 	"github.com/labstack/echo/v4"
 	"github.com/rag594/rustic/rusticTracer"
+	"log"
 	"net/http"
 )
 
@@ -22,7 +23,11 @@ type UserPostResp struct {
 func main() {
 	e := echo.New()
 	e.Use(rusticTracer.Echov4TracerMiddleware("postService"))
-	shutdown := rusticTracer.InitTracer("postService", "dev", rusticTracer.OTLPExporter("localhost", "4318"))
+	exporter, err := rusticTracer.OTLPExporter("localhost", "4318")
+	if err != nil {
+		log.Fatal(err)
+	}
+	shutdown := rusticTracer.InitTracer("postService", "dev", exporter)
 	defer shutdown()
 	e.POST("/create-post", func(c echo.Context) error {
 
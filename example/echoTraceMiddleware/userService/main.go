@@ -6,6 +6,7 @@ import (
 	"github.com/rag594/rustic"
 	"github.com/rag594/rustic/httpClient"
 	"github.com/rag594/rustic/rusticTracer"
+	"log"
 	"net/http"
 	"time"
 )
@@ -26,7 +27,11 @@ type UserPostResp struct {
 func main() {
 	e := echo.New()
 	// you can try out with tracer.StdOutExporter() in your localhost
-	shutdown := rusticTracer.InitTracer("userService", "dev", rusticTracer.OTLPExporter("localhost", "4318"))
+	exporter, err := rusticTracer.OTLPExporter("localhost", "4318")
+	if err != nil {
+		log.Fatal(err)
+	}
+	shutdown := rusticTracer.InitTracer("userService", "dev", exporter)
 
 	defer shutdown()
 	e.Use(rusticTracer.Echov4TracerMiddleware("userService"))
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"log"
 	url2 "net/url"
 	"time"
 
@@ -21,7 +22,11 @@ type UserPost struct {
 
 func main() {
 
-	shutdown := rusticTracer.InitTracer("microserviceA", "dev", rusticTracer.StdOutExporter())
+	exporter, err := rusticTracer.StdOutExporter()
+	if err != nil {
+		log.Fatal(err)
+	}
+	shutdown := rusticTracer.InitTracer("microserviceA", "dev", exporter)
 	defer shutdown()
 
 	// With Circuit breaker
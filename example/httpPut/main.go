@@ -6,6 +6,7 @@ import (
 	"github.com/rag594/rustic"
 	"github.com/rag594/rustic/httpClient"
 	"github.com/rag594/rustic/rusticTracer"
+	"log"
 	"time"
 )
 
@@ -24,7 +25,11 @@ type UserPutResp struct {
 }
 
 func main() {
-	shutdown := rusticTracer.InitTracer("microserviceA", "dev", rusticTracer.StdOutExporter())
+	exporter, err := rusticTracer.StdOutExporter()
+	if err != nil {
+		log.Fatal(err)
+	}
+	shutdown := rusticTracer.InitTracer("microserviceA", "dev", exporter)
 	defer shutdown()
 
 	client := httpClient.NewHTTPClient(httpClient.WithTraceEnabled(true))
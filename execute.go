@@ -6,17 +6,17 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"mime/multipart"
 	"net/http"
 	netUrl "net/url"
 	"os"
+	"regexp"
 	"strings"
 	"time"
 
 	"github.com/rag594/rustic/httpClient"
-	"github.com/rag594/rustic/rusticTracer"
 	"github.com/sony/gobreaker/v2"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // HTTPConfig different http configurations
@@ -25,8 +25,13 @@ type HTTPConfig struct {
 	Timeout             time.Duration
 	Headers             http.Header
 	QueryParams         netUrl.Values
+	Query               any // struct encoded with EncodeQuery and merged into QueryParams
 	FormParams          netUrl.Values
+	Form                any // struct encoded with EncodeQuery and merged into FormParams
 	MultipartFormParams map[string]string
+	PathParams          map[string]string
+	QueryPrecedence     QueryPrecedence
+	Debug               *httpClient.DebugConfig
 	CircuitBreaker      *gobreaker.CircuitBreaker[any] // currently only github.com/sony/gobreaker/v2 is supported
 }
 
@@ -44,9 +49,16 @@ func WithTimeout(t time.Duration) HTTPConfigOptions {
 	}
 }
 
+// WithHeaders sets the headers of the call, merged per key with the headers of the previous options, e.g. the defaults of a Service,
+// hence a key replaces the earlier values of the same key only
 func WithHeaders(c http.Header) HTTPConfigOptions {
 	return func(p *HTTPConfig) {
-		p.Headers = c
+		if p.Headers == nil {
+			p.Headers = http.Header{}
+		}
+		for key, values := range c {
+			p.Headers[http.CanonicalHeaderKey(key)] = append([]string(nil), values...)
+		}
 	}
 }
 
@@ -62,24 +74,73 @@ func WithFormParams(c netUrl.Values) HTTPConfigOptions {
 	}
 }
 
+// WithQueryPrecedence sets how the query params are merged with the query string already in the URL, QueryMerge by default
+func WithQueryPrecedence(p QueryPrecedence) HTTPConfigOptions {
+	return func(config *HTTPConfig) {
+		config.QueryPrecedence = p
+	}
+}
+
+// WithQuery encodes v with EncodeQuery, e.g. a struct with `query:"name,omitempty"` tags, and adds it to the query params
+func WithQuery(v any) HTTPConfigOptions {
+	return func(config *HTTPConfig) {
+		config.Query = v
+	}
+}
+
+// WithForm encodes v with EncodeQuery, e.g. a struct with `query:"name,omitempty"` tags, and adds it to the form params of POSTFormData
+func WithForm(v any) HTTPConfigOptions {
+	return func(config *HTTPConfig) {
+		config.Form = v
+	}
+}
+
 func WithMultiPartFormParams(c map[string]string) HTTPConfigOptions {
 	return func(p *HTTPConfig) {
 		p.MultipartFormParams = c
 	}
 }
 
+// WithPathParams expands the {name} placeholders of a templated URL like /users/{id}/posts/{postId}, each value is path escaped.
+// The unexpanded template is recorded as http.route on the spans and the client metrics
+func WithPathParams(p map[string]string) HTTPConfigOptions {
+	return func(config *HTTPConfig) {
+		config.PathParams = p
+	}
+}
+
+// WithDebug dumps the requests of the call to w as curl commands and wire format with the secrets redacted, see httpClient.NewDebugConfig
+func WithDebug(w io.Writer, opts ...httpClient.DebugOption) HTTPConfigOptions {
+	return func(config *HTTPConfig) {
+		config.Debug = httpClient.NewDebugConfig(w, opts...)
+	}
+}
+
 func WithCircuitBreaker(c *gobreaker.CircuitBreaker[any]) HTTPConfigOptions {
 	return func(config *HTTPConfig) {
 		config.CircuitBreaker = c
 	}
 }
 
+// newHTTPConfig applies the options in order
+func newHTTPConfig(opts []HTTPConfigOptions) *HTTPConfig {
+	config := &HTTPConfig{}
+	for _, opt := range opts {
+		opt(config)
+	}
+	return config
+}
+
 // setupContext prepares the context with timeout and tracing
 func setupContext(ctx context.Context, config *HTTPConfig) (context.Context, func()) {
 	if ctx == nil {
 		ctx = context.Background()
 	}
 
+	if config.Debug != nil {
+		ctx = httpClient.ContextWithDebug(ctx, config.Debug)
+	}
+
 	var cancel context.CancelFunc
 	if config.Timeout != 0 {
 		ctx, cancel = context.WithTimeout(ctx, config.Timeout)
@@ -88,8 +149,7 @@ func setupContext(ctx context.Context, config *HTTPConfig) (context.Context, fun
 	}
 
 	if config.HttpClient.TraceEnabled {
-		tr := rusticTracer.GetTracer(config.HttpClient.ServiceName)
-		ctx, span := tr.Start(ctx, httpClient.GetCallerFunctionName())
+		ctx, span := config.HttpClient.Tracer().Start(ctx, httpClient.GetCallerFunctionName())
 		return ctx, func() {
 			span.End()
 			cancel()
@@ -99,13 +159,51 @@ func setupContext(ctx context.Context, config *HTTPConfig) (context.Context, fun
 	return ctx, cancel
 }
 
-// applyHeaders applies headers to the request
-func applyHeaders(req *http.Request, headers http.Header) {
+// pathParamPattern matches the {name} placeholders of a templated URL
+var pathParamPattern = regexp.MustCompile(`\{([^{}/]+)\}`)
+
+// applyPathParams expands the templated url with the path params and records the template as http.route on the span and in the context
+func applyPathParams(ctx context.Context, url string, config *HTTPConfig) (context.Context, string, error) {
+	if len(config.PathParams) == 0 {
+		return ctx, url, nil
+	}
+
+	var missing []string
+	expanded := pathParamPattern.ReplaceAllStringFunc(url, func(placeholder string) string {
+		name := placeholder[1 : len(placeholder)-1]
+		value, ok := config.PathParams[name]
+		if !ok {
+			missing = append(missing, name)
+			return placeholder
+		}
+		return netUrl.PathEscape(value)
+	})
+	if len(missing) != 0 {
+		return ctx, url, fmt.Errorf("missing path params %s for %s", strings.Join(missing, ", "), url)
+	}
+
+	route := url
+	if parsedURL, err := netUrl.Parse(url); err == nil {
+		route = parsedURL.Path
+	}
+	trace.SpanFromContext(ctx).SetAttributes(httpClient.RouteAttributeKey.String(route))
+
+	return httpClient.ContextWithRoute(ctx, route), expanded, nil
+}
+
+// applyHeaders applies every value of the per-call headers to the request, empty values are skipped.
+// contentType is the one of the verb's body encoding, it is set only if the per-call headers have none
+func applyHeaders(req *http.Request, headers http.Header, contentType string) {
 	for key, values := range headers {
-		if len(values) > 0 && len(values[0]) > 0 {
-			req.Header.Set(key, values[0])
+		for _, value := range values {
+			if value != "" {
+				req.Header.Add(key, value)
+			}
 		}
 	}
+	if contentType != "" && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", contentType)
+	}
 }
 
 // handleResponse processes the HTTP response
@@ -162,52 +260,47 @@ func createRequest(ctx context.Context, method, url string, body io.Reader) (*ht
 
 // GET http method with Res as response type
 func GET[Res any](ctx context.Context, url string, opts ...HTTPConfigOptions) (*Res, error) {
-	config := &HTTPConfig{}
-	for _, opt := range opts {
-		opt(config)
-	}
-
-	if config.Headers == nil {
-		config.Headers = http.Header{}
-	}
-	config.Headers.Set("Content-Type", "application/json")
+	config := newHTTPConfig(opts)
 
 	ctx, cancel := setupContext(ctx, config)
 	defer cancel()
 
-	parsedURL, err := netUrl.Parse(url)
+	ctx, url, err := applyPathParams(ctx, url, config)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
-	if len(config.QueryParams) != 0 {
-		parsedURL.RawQuery = config.QueryParams.Encode()
+	url, err = buildURL(url, config)
+	if err != nil {
+		return nil, err
 	}
 
-	req, err := createRequest(ctx, http.MethodGet, parsedURL.String(), nil)
+	req, err := createRequest(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	applyHeaders(req, config.Headers)
+	applyHeaders(req, config.Headers, "application/json")
 	return executeRequest[Res](config.HttpClient, req, config.CircuitBreaker)
 }
 
 // POST http method with Req as request type and Res as response type
 func POST[Req, Res any](ctx context.Context, url string, req *Req, opts ...HTTPConfigOptions) (*Res, error) {
-	config := &HTTPConfig{}
-	for _, opt := range opts {
-		opt(config)
-	}
-
-	if config.Headers == nil {
-		config.Headers = http.Header{}
-	}
-	config.Headers.Set("Content-Type", "application/json")
+	config := newHTTPConfig(opts)
 
 	ctx, cancel := setupContext(ctx, config)
 	defer cancel()
 
+	ctx, url, err := applyPathParams(ctx, url, config)
+	if err != nil {
+		return nil, err
+	}
+
+	url, err = buildURL(url, config)
+	if err != nil {
+		return nil, err
+	}
+
 	jsonBody, err := json.Marshal(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request body: %w", err)
@@ -218,25 +311,27 @@ func POST[Req, Res any](ctx context.Context, url string, req *Req, opts ...HTTPC
 		return nil, err
 	}
 
-	applyHeaders(request, config.Headers)
+	applyHeaders(request, config.Headers, "application/json")
 	return executeRequest[Res](config.HttpClient, request, config.CircuitBreaker)
 }
 
 // PUT http method with Req as request type and Res as response type
 func PUT[Req, Res any](ctx context.Context, url string, req *Req, opts ...HTTPConfigOptions) (*Res, error) {
-	config := &HTTPConfig{}
-	for _, opt := range opts {
-		opt(config)
-	}
-
-	if config.Headers == nil {
-		config.Headers = http.Header{}
-	}
-	config.Headers.Set("Content-Type", "application/json")
+	config := newHTTPConfig(opts)
 
 	ctx, cancel := setupContext(ctx, config)
 	defer cancel()
 
+	ctx, url, err := applyPathParams(ctx, url, config)
+	if err != nil {
+		return nil, err
+	}
+
+	url, err = buildURL(url, config)
+	if err != nil {
+		return nil, err
+	}
+
 	jsonBody, err := json.Marshal(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request body: %w", err)
@@ -247,48 +342,58 @@ func PUT[Req, Res any](ctx context.Context, url string, req *Req, opts ...HTTPCo
 		return nil, err
 	}
 
-	applyHeaders(request, config.Headers)
+	applyHeaders(request, config.Headers, "application/json")
 	return executeRequest[Res](config.HttpClient, request, config.CircuitBreaker)
 }
 
 // POSTFormData with Res as response type and allows application/x-www-form-urlencoded -> formData
 func POSTFormData[Res any](ctx context.Context, url string, opts ...HTTPConfigOptions) (*Res, error) {
-	config := &HTTPConfig{}
-	for _, opt := range opts {
-		opt(config)
+	config := newHTTPConfig(opts)
+
+	ctx, cancel := setupContext(ctx, config)
+	defer cancel()
+
+	ctx, url, err := applyPathParams(ctx, url, config)
+	if err != nil {
+		return nil, err
 	}
 
-	if config.Headers == nil {
-		config.Headers = http.Header{}
+	url, err = buildURL(url, config)
+	if err != nil {
+		return nil, err
 	}
-	config.Headers.Set("Content-Type", "application/x-www-form-urlencoded")
 
-	ctx, cancel := setupContext(ctx, config)
-	defer cancel()
+	formParams, err := mergeValues(config.FormParams, config.Form)
+	if err != nil {
+		return nil, err
+	}
 
-	request, err := createRequest(ctx, http.MethodPost, url, strings.NewReader(config.FormParams.Encode()))
+	request, err := createRequest(ctx, http.MethodPost, url, strings.NewReader(formParams.Encode()))
 	if err != nil {
 		return nil, err
 	}
 
-	applyHeaders(request, config.Headers)
+	applyHeaders(request, config.Headers, "application/x-www-form-urlencoded")
 	return executeRequest[Res](config.HttpClient, request, config.CircuitBreaker)
 }
 
 // POSTMultiPartFormData with Res as response type, map of files with key as fieldName and value as filePath
 func POSTMultiPartFormData[Res any](ctx context.Context, url string, files map[string]string, opts ...HTTPConfigOptions) (*Res, error) {
-	config := &HTTPConfig{}
-	for _, opt := range opts {
-		opt(config)
-	}
-
-	if config.Headers != nil {
-		config.Headers = make(http.Header)
-	}
+	config := newHTTPConfig(opts)
 
 	ctx, cancel := setupContext(ctx, config)
 	defer cancel()
 
+	ctx, url, err := applyPathParams(ctx, url, config)
+	if err != nil {
+		return nil, err
+	}
+
+	url, err = buildURL(url, config)
+	if err != nil {
+		return nil, err
+	}
+
 	body := &bytes.Buffer{}
 	writer := multipart.NewWriter(body)
 
@@ -328,7 +433,8 @@ func POSTMultiPartFormData[Res any](ctx context.Context, url string, files map[s
 		return nil, err
 	}
 
+	applyHeaders(request, config.Headers, "")
+	// the boundary of the body is only known by the writer, hence it always sets the Content-Type
 	request.Header.Set("Content-Type", writer.FormDataContentType())
-	applyHeaders(request, config.Headers)
 	return executeRequest[Res](config.HttpClient, request, config.CircuitBreaker)
 }
@@ -1,6 +1,7 @@
 package rustic
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"io"
@@ -9,13 +10,19 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/rag594/rustic/httpClient"
+	"github.com/rag594/rustic/rusticTracer"
 	"github.com/sony/gobreaker/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkTrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 type TestRequest struct {
@@ -448,3 +455,234 @@ func TestTimeout(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "context deadline exceeded")
 }
+
+func TestTracerProviderInjection(t *testing.T) {
+	recorder := rusticTracer.NewSpanRecorder()
+	tp, propagator := rusticTracer.NewTracerProvider("test-service", "test", recorder,
+		rusticTracer.WithSyncExport(),
+		rusticTracer.WithPropagators(rusticTracer.PropagatorB3Multi),
+	)
+	t.Cleanup(func() { _ = tp.Shutdown(context.Background()) })
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NotEmpty(t, r.Header.Get("X-B3-TraceId"))
+		assert.Empty(t, r.Header.Get("traceparent"))
+
+		w.WriteHeader(http.StatusOK)
+		err := json.NewEncoder(w).Encode(TestResponse{ID: 1, Name: "John", Age: 30})
+		require.NoError(t, err)
+	}))
+	t.Cleanup(server.Close)
+
+	client := httpClient.NewHTTPClient(
+		httpClient.WithTraceEnabled(true),
+		httpClient.WithServiceName("test-service"),
+		httpClient.WithTracerProvider(tp),
+		httpClient.WithPropagator(propagator),
+	)
+
+	_, err := GET[TestResponse](context.Background(), server.URL, WithHttpClient(client))
+	require.NoError(t, err)
+
+	spans := recorder.Spans()
+	require.Len(t, spans, 2)
+	// the transport span ends first and is a child of the rustic span
+	assert.Len(t, recorder.ChildrenOf(spans[1]), 1)
+}
+
+func TestService(t *testing.T) {
+	server, client := setupTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/users/42", r.URL.Path)
+		w.WriteHeader(http.StatusOK)
+		err := json.NewEncoder(w).Encode(TestResponse{ID: 42, Name: r.Header.Get("X-Caller") + " " + r.Header.Get("Authorization"), Age: 30})
+		require.NoError(t, err)
+	})
+
+	svc := NewService(server.URL+"/api/",
+		WithHttpClient(client),
+		WithTimeout(time.Second),
+		WithHeaders(http.Header{"X-Caller": []string{"default"}, "Authorization": []string{"Bearer token"}}),
+	)
+	assert.Equal(t, server.URL+"/api/users/42", svc.Path("/users/%d", 42))
+	assert.Equal(t, server.URL+"/api/users/42", svc.Path("users/42"))
+
+	resp, err := GET[TestResponse](context.Background(), svc.Path("/users/%d", 42), svc.Options()...)
+	require.NoError(t, err)
+	assert.Equal(t, "default Bearer token", resp.Name)
+
+	// per-call options override the defaults, headers per key
+	resp, err = GET[TestResponse](context.Background(), svc.Path("/users/%d", 42),
+		svc.Options(WithHeaders(http.Header{"x-caller": []string{"per-call"}}))...,
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "per-call Bearer token", resp.Name)
+
+	resp, err = GET[TestResponse](context.Background(), svc.Path("/users/%d", 42), svc.Options()...)
+	require.NoError(t, err)
+	assert.Equal(t, "default Bearer token", resp.Name, "per-call headers do not leak into the defaults")
+
+	// calls not made through the service do not get its defaults
+	resp, err = GET[TestResponse](context.Background(), svc.Path("/users/%d", 42), WithHttpClient(client))
+	require.NoError(t, err)
+	assert.Equal(t, " ", resp.Name)
+}
+
+func TestPathParams(t *testing.T) {
+	recorder := rusticTracer.NewSpanRecorder()
+	tp, _ := rusticTracer.NewTracerProvider("test-service", "test", recorder, rusticTracer.WithSyncExport())
+	t.Cleanup(func() { _ = tp.Shutdown(context.Background()) })
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/users/john%2Fdoe/posts/1", r.RequestURI)
+		w.WriteHeader(http.StatusOK)
+		err := json.NewEncoder(w).Encode(TestResponse{ID: 1, Name: "John", Age: 30})
+		require.NoError(t, err)
+	}))
+	t.Cleanup(server.Close)
+
+	client := httpClient.NewHTTPClient(httpClient.WithTraceEnabled(true), httpClient.WithTracerProvider(tp))
+
+	_, err := GET[TestResponse](context.Background(), server.URL+"/users/{id}/posts/{postId}",
+		WithHttpClient(client),
+		WithPathParams(map[string]string{"id": "john/doe", "postId": "1"}),
+	)
+	require.NoError(t, err)
+
+	spans := recorder.Spans()
+	require.Len(t, spans, 2)
+	for _, span := range spans {
+		assert.Contains(t, span.Attributes(), httpClient.RouteAttributeKey.String("/users/{id}/posts/{postId}"))
+	}
+
+	_, err = GET[TestResponse](context.Background(), server.URL+"/users/{id}/posts/{postId}",
+		WithHttpClient(client),
+		WithPathParams(map[string]string{"id": "1"}),
+	)
+	assert.ErrorContains(t, err, "missing path params postId")
+}
+
+func TestHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, []string{"10.0.0.1", "10.0.0.2"}, r.Header.Values("X-Forwarded-For"))
+		assert.Equal(t, "rustic-test", r.Header.Get("User-Agent"))
+		switch r.Method {
+		case http.MethodGet:
+			assert.Equal(t, []string{"application/xml"}, r.Header.Values("Accept"), "per-call headers win over client defaults")
+			assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		case http.MethodPost:
+			assert.Equal(t, []string{"application/json"}, r.Header.Values("Accept"))
+			assert.Equal(t, "application/merge-patch+json", r.Header.Get("Content-Type"), "per-call Content-Type wins over the encoding one")
+		case http.MethodPut:
+			assert.Equal(t, "application/json", r.Header.Get("Content-Type"), "the encoding Content-Type wins over client defaults")
+		}
+		w.WriteHeader(http.StatusOK)
+		err := json.NewEncoder(w).Encode(TestResponse{ID: 1})
+		require.NoError(t, err)
+	}))
+	t.Cleanup(server.Close)
+
+	client := httpClient.NewHTTPClient(
+		httpClient.WithUserAgent("rustic-test"),
+		httpClient.WithAccept("application/json"),
+		httpClient.WithDefaultHeaders(http.Header{"Content-Type": {"text/plain"}}),
+	)
+	forwarded := http.Header{"X-Forwarded-For": {"10.0.0.1", "10.0.0.2"}}
+	ctx := context.Background()
+
+	_, err := GET[TestResponse](ctx, server.URL, WithHttpClient(client),
+		WithHeaders(http.Header{"X-Forwarded-For": forwarded["X-Forwarded-For"], "Accept": {"application/xml"}}))
+	require.NoError(t, err)
+
+	_, err = POST[TestRequest, TestResponse](ctx, server.URL, &TestRequest{}, WithHttpClient(client),
+		WithHeaders(http.Header{"X-Forwarded-For": forwarded["X-Forwarded-For"], "Content-Type": {"application/merge-patch+json"}}))
+	require.NoError(t, err)
+
+	_, err = PUT[TestRequest, TestResponse](ctx, server.URL, &TestRequest{}, WithHttpClient(client), WithHeaders(forwarded))
+	require.NoError(t, err)
+}
+
+func TestDebug(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		err := json.NewEncoder(w).Encode(TestResponse{ID: 1})
+		require.NoError(t, err)
+	}))
+	t.Cleanup(server.Close)
+
+	client := httpClient.NewHTTPClient()
+
+	var dump bytes.Buffer
+	_, err := POST[TestRequest, TestResponse](context.Background(), server.URL+"/users", &TestRequest{Name: "John", Age: 30},
+		WithHttpClient(client),
+		WithHeaders(http.Header{"Authorization": {"Bearer token"}}),
+		WithDebug(&dump, httpClient.WithCurlDump()),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, "curl -X POST '"+server.URL+"/users' -H 'Authorization: REDACTED' -H 'Content-Type: application/json'"+
+		` --data-raw '{"age":30,"name":"John"}'`+"\n", dump.String())
+
+	dump.Reset()
+	_, err = GET[TestResponse](context.Background(), server.URL, WithHttpClient(client))
+	require.NoError(t, err)
+	assert.Empty(t, dump.String(), "the debug dump is per call")
+}
+
+func TestCoalescing(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		<-release
+		assert.NoError(t, json.NewEncoder(w).Encode(TestResponse{ID: 1, Name: "John"}))
+	}))
+	t.Cleanup(server.Close)
+
+	// the callers which joined the in-flight call are marked on their live span
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdkTrace.NewTracerProvider(sdkTrace.WithSpanProcessor(recorder))
+	t.Cleanup(func() { _ = tp.Shutdown(context.Background()) })
+	client := httpClient.NewHTTPClient(
+		httpClient.WithCoalescing(),
+		httpClient.WithTraceEnabled(true),
+		httpClient.WithTracerProvider(tp),
+	)
+	coalesced := func() int {
+		n := 0
+		for _, span := range recorder.Started() {
+			for _, attr := range span.Attributes() {
+				if attr == attribute.Bool("http.request.coalesced", true) {
+					n++
+				}
+			}
+		}
+		return n
+	}
+
+	const callers = 5
+	var wg sync.WaitGroup
+	results := make([]*TestResponse, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			res, err := GET[TestResponse](context.Background(), server.URL+"/users/{id}",
+				WithHttpClient(client),
+				WithPathParams(map[string]string{"id": "1"}),
+			)
+			assert.NoError(t, err)
+			results[i] = res
+		}(i)
+	}
+
+	require.Eventually(t, func() bool { return calls.Load() == 1 && coalesced() == callers-1 }, time.Second, time.Millisecond,
+		"every caller is waiting for the in-flight call before the server answers")
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load())
+	results[0].Name = "Jane"
+	for _, res := range results[1:] {
+		assert.Equal(t, &TestResponse{ID: 1, Name: "John"}, res, "every caller decodes its own copy")
+	}
+}
@@ -0,0 +1,109 @@
+package httpClient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Token an access token sent as "Authorization: <TokenType> <AccessToken>"
+type Token struct {
+	AccessToken string
+	TokenType   string    // Bearer when empty
+	Expiry      time.Time // the token never expires when zero
+}
+
+// authorization returns the value of the Authorization header
+func (t *Token) authorization() string {
+	tokenType := t.TokenType
+	if tokenType == "" {
+		tokenType = "Bearer"
+	}
+	return tokenType + " " + t.AccessToken
+}
+
+// TokenSource returns the token to authenticate the requests, implementations must be safe for concurrent use
+type TokenSource interface {
+	Token(ctx context.Context) (*Token, error)
+}
+
+// TokenInvalidator implemented by the token sources caching their token, the HTTPClient invalidates the token rejected with a 401
+type TokenInvalidator interface {
+	Invalidate(token *Token)
+}
+
+// staticTokenSource always returns the same token
+type staticTokenSource struct {
+	token *Token
+}
+
+// StaticTokenSource returns a TokenSource always returning the bearer token
+func StaticTokenSource(accessToken string) TokenSource {
+	return staticTokenSource{token: &Token{AccessToken: accessToken}}
+}
+
+func (s staticTokenSource) Token(context.Context) (*Token, error) {
+	return s.token, nil
+}
+
+// WithTokenSource authenticates every request with the token of ts, unless the request has its own Authorization header.
+// A request rejected with 401 is retried once with a fresh token if its body can be replayed
+func WithTokenSource(ts TokenSource) HTTPClientOption {
+	return func(client *HTTPClient) {
+		client.TokenSource = ts
+	}
+}
+
+// authenticate sets the Authorization header of the requests out of the TokenSource and retries once on 401
+func (c *HTTPClient) authenticate(next RoundTripFunc) RoundTripFunc {
+	return func(request *http.Request) (*http.Response, error) {
+		if request.Header.Get("Authorization") != "" {
+			return next(request)
+		}
+
+		token, err := c.TokenSource.Token(request.Context())
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := next(withAuthorization(request, token))
+		if err != nil || resp.StatusCode != http.StatusUnauthorized {
+			return resp, err
+		}
+
+		invalidator, ok := c.TokenSource.(TokenInvalidator)
+		if !ok || (request.Body != nil && request.Body != http.NoBody && request.GetBody == nil) {
+			return resp, nil
+		}
+		invalidator.Invalidate(token)
+
+		fresh, err := c.TokenSource.Token(request.Context())
+		if err != nil || fresh.AccessToken == token.AccessToken {
+			return resp, nil
+		}
+
+		retry := withAuthorization(request, fresh)
+		retry = retry.WithContext(ContextWithAttempt(retry.Context(), AttemptFromContext(request.Context())+1))
+		if request.GetBody != nil {
+			if retry.Body, err = request.GetBody(); err != nil {
+				return resp, nil
+			}
+		}
+		// the rejected response is dropped, drain it to reuse the connection
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		return next(retry)
+	}
+}
+
+// withAuthorization returns a copy of the request with the Authorization header of the token
+func withAuthorization(request *http.Request, token *Token) *http.Request {
+	authorized := request.Clone(request.Context())
+	if authorized.Header == nil {
+		authorized.Header = http.Header{}
+	}
+	authorized.Header.Set("Authorization", token.authorization())
+	return authorized
+}
@@ -0,0 +1,232 @@
+package httpClient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTokenServer stub token endpoint issuing token-1, token-2... valid for expiresIn seconds
+func newTokenServer(t *testing.T, expiresIn int, delay time.Duration) (*httptest.Server, *atomic.Int32) {
+	var issued atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clientID, clientSecret, ok := r.BasicAuth()
+		if !ok || clientID != "client" || clientSecret != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_client", "error_description": "bad credentials"})
+			return
+		}
+		assert.Equal(t, "client_credentials", r.PostFormValue("grant_type"))
+		assert.Equal(t, "read write", r.PostFormValue("scope"))
+
+		time.Sleep(delay)
+		n := issued.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"access_token": fmt.Sprintf("token-%d", n),
+			"token_type":   "bearer",
+			"expires_in":   expiresIn,
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server, &issued
+}
+
+func TestClientCredentialsSingleFlight(t *testing.T) {
+	tokenServer, issued := newTokenServer(t, 3600, 50*time.Millisecond)
+	source := ClientCredentials(tokenServer.URL, "client", "secret", WithScopes("read", "write"))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			token, err := source.Token(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, "token-1", token.AccessToken)
+			assert.Equal(t, "Bearer", token.TokenType)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), issued.Load())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	source.Invalidate(&Token{AccessToken: "token-1"})
+	_, err := source.Token(ctx)
+	require.NoError(t, err, "only the cached token is invalidated")
+}
+
+func TestClientCredentialsRefresh(t *testing.T) {
+	tokenServer, issued := newTokenServer(t, 100, 0)
+	source := ClientCredentials(tokenServer.URL, "client", "secret", WithScopes("read", "write"))
+
+	var elapsed atomic.Int64
+	start := time.Now()
+	source.now = func() time.Time { return start.Add(time.Duration(elapsed.Load())) }
+
+	token, err := source.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", token.AccessToken)
+
+	// the refresh window is capped at half of the lifetime, 50s
+	elapsed.Store(int64(40 * time.Second))
+	token, err = source.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", token.AccessToken)
+	assert.Equal(t, int32(1), issued.Load())
+
+	elapsed.Store(int64(60 * time.Second))
+	token, err = source.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", token.AccessToken, "the valid token is returned while refreshing")
+	assert.Eventually(t, func() bool {
+		token, err := source.Token(context.Background())
+		return err == nil && token.AccessToken == "token-2"
+	}, time.Second, 10*time.Millisecond)
+
+	elapsed.Store(int64(500 * time.Second))
+	token, err = source.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "token-3", token.AccessToken, "an expired token is fetched synchronously")
+}
+
+func TestClientCredentialsRefreshBackoff(t *testing.T) {
+	var fetches atomic.Int32
+	var failing atomic.Bool
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		if failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"access_token": "token", "token_type": "bearer", "expires_in": 100})
+	}))
+	t.Cleanup(tokenServer.Close)
+
+	source := ClientCredentials(tokenServer.URL, "client", "secret")
+	var elapsed atomic.Int64
+	start := time.Now()
+	source.now = func() time.Time { return start.Add(time.Duration(elapsed.Load())) }
+
+	// token returns the cached token once the background refresh it may have started is done
+	token := func() {
+		_, err := source.Token(context.Background())
+		require.NoError(t, err)
+		assert.Eventually(t, func() bool {
+			source.mu.Lock()
+			defer source.mu.Unlock()
+			return source.flight == nil
+		}, time.Second, time.Millisecond)
+	}
+
+	token()
+	failing.Store(true)
+
+	elapsed.Store(int64(60 * time.Second))
+	for i := 0; i < 10; i++ {
+		token()
+	}
+	assert.Equal(t, int32(2), fetches.Load(), "a failed refresh is not retried on every call")
+
+	elapsed.Add(int64(time.Second))
+	token()
+	token()
+	assert.Equal(t, int32(3), fetches.Load(), "the refresh is retried after 1s")
+
+	elapsed.Add(int64(time.Second))
+	token()
+	assert.Equal(t, int32(3), fetches.Load(), "the backoff doubles")
+	elapsed.Add(int64(time.Second))
+	token()
+	assert.Equal(t, int32(4), fetches.Load())
+
+	failing.Store(false)
+	elapsed.Add(int64(4 * time.Second))
+	token()
+	token()
+	assert.Equal(t, int32(5), fetches.Load(), "a successful refresh resets the backoff")
+}
+
+func TestClientCredentialsError(t *testing.T) {
+	tokenServer, _ := newTokenServer(t, 3600, 0)
+	source := ClientCredentials(tokenServer.URL, "client", "wrong")
+
+	_, err := source.Token(context.Background())
+	assert.ErrorContains(t, err, "token endpoint returned 401: invalid_client bad credentials")
+}
+
+func TestTokenSourceRetriesOnUnauthorized(t *testing.T) {
+	tokenServer, issued := newTokenServer(t, 3600, 0)
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, `{"name":"john"}`, string(body))
+		if r.Header.Get("Authorization") != "Bearer token-2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+
+	source := ClientCredentials(tokenServer.URL, "client", "secret", WithScopes("read", "write"))
+	client := NewHTTPClient(WithTokenSource(source))
+
+	req, err := http.NewRequest(http.MethodPost, server.URL, strings.NewReader(`{"name":"john"}`))
+	require.NoError(t, err)
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	assert.Equal(t, int32(2), calls.Load())
+	assert.Equal(t, int32(2), issued.Load())
+	assert.Empty(t, req.Header.Get("Authorization"), "the caller's request is not mutated")
+
+	// still rejected with the fresh token, the 401 is returned without further retries
+	req, err = http.NewRequest(http.MethodPost, server.URL, strings.NewReader(`{"name":"john"}`))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer own")
+
+	resp, err = client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, "a request with its own Authorization is not authenticated")
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestStaticTokenSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer static", r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewHTTPClient(WithTokenSource(StaticTokenSource("static")))
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+}
@@ -0,0 +1,435 @@
+package httpClient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// cacheStatusKey span and metric attribute holding how the cache answered the request
+const cacheStatusKey = attribute.Key("http.cache.status")
+
+// statuses of the cache lookups recorded as http.cache.status
+const (
+	CacheHit         = "hit"         // fresh response served from the cache
+	CacheStale       = "stale"       // stale response served while it is revalidated in the background
+	CacheRevalidated = "revalidated" // stored response served once the server answered 304 Not Modified
+	CacheMiss        = "miss"        // response fetched from the server
+	CacheBypass      = "bypass"      // request the cache does not handle, e.g. no-store, conditional or not a GET
+)
+
+// cacheableStatus the status codes cacheable by default, RFC 9110 section 15.1
+var cacheableStatus = map[int]bool{
+	http.StatusOK: true, http.StatusNonAuthoritativeInfo: true, http.StatusNoContent: true,
+	http.StatusMultipleChoices: true, http.StatusMovedPermanently: true, http.StatusPermanentRedirect: true,
+	http.StatusNotFound: true, http.StatusMethodNotAllowed: true, http.StatusGone: true,
+	http.StatusRequestURITooLong: true, http.StatusNotImplemented: true,
+}
+
+// WithCache caches the GET responses in storage following RFC 9111 as a private cache: max-age, Expires, no-store, no-cache,
+// must-revalidate, Vary and stale-while-revalidate are honored and the stale responses are revalidated with
+// If-None-Match/If-Modified-Since. Responses without freshness information nor validator are not stored, nor the responses
+// to requests with Authorization unless public, s-maxage or must-revalidate allows sharing them, RFC 9111 section 3.5,
+// as the stored responses are served to every caller of the client
+func WithCache(storage CacheStorage) HTTPClientOption {
+	return func(client *HTTPClient) {
+		client.Cache = storage
+	}
+}
+
+// cachedResponse stored response
+type cachedResponse struct {
+	StatusCode   int                 `json:"status_code"`
+	Header       http.Header         `json:"header"`
+	Body         []byte              `json:"body"`
+	RequestTime  time.Time           `json:"request_time"`
+	ResponseTime time.Time           `json:"response_time"`
+	Vary         map[string][]string `json:"vary"` // values of the request headers named by Vary
+}
+
+// cacheTransport private HTTP cache in front of the transport
+type cacheTransport struct {
+	next     http.RoundTripper
+	storage  CacheStorage
+	requests metric.Int64Counter
+
+	now func() time.Time
+
+	mu           sync.Mutex
+	revalidating map[string]bool
+}
+
+func newCacheTransport(next http.RoundTripper, storage CacheStorage, client *HTTPClient) *cacheTransport {
+	meterProvider := client.MeterProvider
+	if meterProvider == nil {
+		meterProvider = otel.GetMeterProvider()
+	}
+	requests, err := meterProvider.Meter(client.ServiceName).Int64Counter("http.client.cache.requests",
+		metric.WithUnit("{request}"),
+		metric.WithDescription("Requests looked up in the HTTP cache by http.cache.status"),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	return &cacheTransport{
+		next:         next,
+		storage:      storage,
+		requests:     requests,
+		now:          time.Now,
+		revalidating: map[string]bool{},
+	}
+}
+
+func (t *cacheTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	key := cacheKey(request)
+
+	if request.Method != http.MethodGet {
+		resp, err := t.next.RoundTrip(request)
+		// a successful unsafe request invalidates the stored response of the URL
+		if err == nil && request.Method != http.MethodHead && request.Method != http.MethodOptions && resp.StatusCode < 400 {
+			t.storage.Delete(key)
+		}
+		t.record(request.Context(), CacheBypass)
+		return resp, err
+	}
+
+	requestDirectives := parseCacheControl(request.Header)
+	if _, noStore := requestDirectives["no-store"]; noStore || isConditional(request) {
+		t.record(request.Context(), CacheBypass)
+		return t.next.RoundTrip(request)
+	}
+
+	entry := t.load(key, request)
+	if entry == nil {
+		t.record(request.Context(), CacheMiss)
+		return t.fetch(key, request)
+	}
+
+	responseDirectives := parseCacheControl(entry.Header)
+	age := t.age(entry)
+	lifetime := freshnessLifetime(entry, responseDirectives)
+	_, requestNoCache := requestDirectives["no-cache"]
+	_, responseNoCache := responseDirectives["no-cache"]
+	_, mustRevalidate := responseDirectives["must-revalidate"]
+	maxAge, hasMaxAge := directiveSeconds(requestDirectives, "max-age")
+	canServe := !requestNoCache && !responseNoCache && (!hasMaxAge || age <= maxAge)
+
+	if canServe && age < lifetime {
+		t.record(request.Context(), CacheHit)
+		return entry.response(request, age), nil
+	}
+
+	swr, hasSWR := directiveSeconds(responseDirectives, "stale-while-revalidate")
+	if canServe && hasSWR && !mustRevalidate && age < lifetime+swr {
+		t.record(request.Context(), CacheStale)
+		t.revalidateInBackground(key, request, entry)
+		return entry.response(request, age), nil
+	}
+
+	resp, status, err := t.revalidate(key, request, entry)
+	if err != nil {
+		return nil, err
+	}
+	t.record(request.Context(), status)
+	return resp, nil
+}
+
+// record records the cache status on the span of the request and in the metric
+func (t *cacheTransport) record(ctx context.Context, status string) {
+	trace.SpanFromContext(ctx).SetAttributes(cacheStatusKey.String(status))
+	if t.requests != nil {
+		t.requests.Add(ctx, 1, metric.WithAttributes(cacheStatusKey.String(status)))
+	}
+}
+
+// load returns the stored response of the key if it was stored for the same values of the Vary headers
+func (t *cacheTransport) load(key string, request *http.Request) *cachedResponse {
+	b, ok := t.storage.Get(key)
+	if !ok {
+		return nil
+	}
+	var entry cachedResponse
+	if err := json.Unmarshal(b, &entry); err != nil {
+		t.storage.Delete(key)
+		return nil
+	}
+	for name, values := range entry.Vary {
+		if strings.Join(request.Header.Values(name), ",") != strings.Join(values, ",") {
+			return nil
+		}
+	}
+	return &entry
+}
+
+// fetch sends the request and stores its response if cacheable
+func (t *cacheTransport) fetch(key string, request *http.Request) (*http.Response, error) {
+	requestTime := t.now()
+	resp, err := t.next.RoundTrip(request)
+	if err != nil {
+		return nil, err
+	}
+	return t.store(key, request, resp, requestTime)
+}
+
+// revalidate sends the request conditioned on the validators of the stored response, a 304 refreshes the stored response.
+// It returns the cache status of the response, recorded by the caller as the background revalidations record none
+func (t *cacheTransport) revalidate(key string, request *http.Request, entry *cachedResponse) (*http.Response, string, error) {
+	conditional := request.Clone(request.Context())
+	if etag := entry.Header.Get("ETag"); etag != "" {
+		conditional.Header.Set("If-None-Match", etag)
+	}
+	if lastModified := entry.Header.Get("Last-Modified"); lastModified != "" {
+		conditional.Header.Set("If-Modified-Since", lastModified)
+	}
+
+	requestTime := t.now()
+	resp, err := t.next.RoundTrip(conditional)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if resp.StatusCode != http.StatusNotModified {
+		resp, err = t.store(key, request, resp, requestTime)
+		return resp, CacheMiss, err
+	}
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	// the 304 headers update the stored ones, RFC 9111 section 4.3.4
+	for name, values := range resp.Header {
+		if name == "Content-Length" {
+			continue
+		}
+		entry.Header[name] = values
+	}
+	entry.RequestTime = requestTime
+	entry.ResponseTime = t.now()
+	t.save(key, entry)
+
+	return entry.response(request, t.age(entry)), CacheRevalidated, nil
+}
+
+// revalidateInBackground revalidates the stored response once at a time per key, without the cancellation of the request
+func (t *cacheTransport) revalidateInBackground(key string, request *http.Request, entry *cachedResponse) {
+	t.mu.Lock()
+	if t.revalidating[key] {
+		t.mu.Unlock()
+		return
+	}
+	t.revalidating[key] = true
+	t.mu.Unlock()
+
+	background := request.Clone(context.WithoutCancel(request.Context()))
+	go func() {
+		defer func() {
+			t.mu.Lock()
+			delete(t.revalidating, key)
+			t.mu.Unlock()
+		}()
+
+		resp, _, err := t.revalidate(key, background, entry.clone())
+		if err == nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+	}()
+}
+
+// store stores the response if cacheable and returns it with a body still readable
+func (t *cacheTransport) store(key string, request *http.Request, resp *http.Response, requestTime time.Time) (*http.Response, error) {
+	directives := parseCacheControl(resp.Header)
+	_, noStore := directives["no-store"]
+	vary := varyHeaders(resp.Header)
+	cacheable := cacheableStatus[resp.StatusCode] && !noStore && !slices.Contains(vary, "*") && shareable(request, directives) &&
+		(freshnessLifetime(&cachedResponse{Header: resp.Header, ResponseTime: t.now()}, directives) > 0 ||
+			resp.Header.Get("ETag") != "" || resp.Header.Get("Last-Modified") != "")
+	if !cacheable {
+		if noStore {
+			t.storage.Delete(key)
+		}
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	entry := &cachedResponse{
+		StatusCode:   resp.StatusCode,
+		Header:       resp.Header.Clone(),
+		Body:         body,
+		RequestTime:  requestTime,
+		ResponseTime: t.now(),
+		Vary:         map[string][]string{},
+	}
+	for _, name := range vary {
+		entry.Vary[name] = request.Header.Values(name)
+	}
+	t.save(key, entry)
+
+	return resp, nil
+}
+
+func (t *cacheTransport) save(key string, entry *cachedResponse) {
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	t.storage.Set(key, b)
+}
+
+// age returns the current age of the stored response, RFC 9111 section 4.2.3
+func (t *cacheTransport) age(entry *cachedResponse) time.Duration {
+	apparentAge := time.Duration(0)
+	if date, err := http.ParseTime(entry.Header.Get("Date")); err == nil && entry.ResponseTime.After(date) {
+		apparentAge = entry.ResponseTime.Sub(date)
+	}
+
+	ageValue, _ := strconv.Atoi(entry.Header.Get("Age"))
+	correctedAge := time.Duration(ageValue)*time.Second + entry.ResponseTime.Sub(entry.RequestTime)
+
+	return max(apparentAge, correctedAge) + t.now().Sub(entry.ResponseTime)
+}
+
+// clone returns a deep copy of the stored response, the body being never modified it is shared
+func (e *cachedResponse) clone() *cachedResponse {
+	c := *e
+	c.Header = e.Header.Clone()
+	c.Vary = make(map[string][]string, len(e.Vary))
+	for name, values := range e.Vary {
+		c.Vary[name] = slices.Clone(values)
+	}
+	return &c
+}
+
+// response builds the response out of the stored one
+func (e *cachedResponse) response(request *http.Request, age time.Duration) *http.Response {
+	header := e.Header.Clone()
+	header.Set("Age", strconv.Itoa(int(age.Seconds())))
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode)),
+		StatusCode:    e.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       request,
+	}
+}
+
+// freshnessLifetime returns how long the response is fresh: max-age, else Expires minus Date, RFC 9111 section 4.2.1
+func freshnessLifetime(entry *cachedResponse, directives map[string]string) time.Duration {
+	if maxAge, ok := directiveSeconds(directives, "max-age"); ok {
+		return maxAge
+	}
+
+	expires := entry.Header.Get("Expires")
+	if expires == "" {
+		return 0
+	}
+	expiresAt, err := http.ParseTime(expires)
+	if err != nil {
+		return 0
+	}
+	date, err := http.ParseTime(entry.Header.Get("Date"))
+	if err != nil {
+		date = entry.ResponseTime
+	}
+	return expiresAt.Sub(date)
+}
+
+// cacheKey returns the key of the stored response of the request URL
+func cacheKey(request *http.Request) string {
+	return http.MethodGet + " " + request.URL.String()
+}
+
+// shareable reports whether the response can be served to every caller of the client, the response to a request with
+// Authorization only when explicitly allowed, RFC 9111 section 3.5
+func shareable(request *http.Request, directives map[string]string) bool {
+	if request.Header.Get("Authorization") == "" {
+		return true
+	}
+	for _, name := range []string{"public", "s-maxage", "must-revalidate"} {
+		if _, ok := directives[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// isConditional reports whether the caller handles the validation or asks for a range itself
+func isConditional(request *http.Request) bool {
+	for _, name := range []string{"If-None-Match", "If-Modified-Since", "If-Match", "If-Unmodified-Since", "If-Range", "Range"} {
+		if request.Header.Get(name) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// parseCacheControl returns the lowercased directives of the Cache-Control headers with their unquoted values,
+// Pragma: no-cache is read as Cache-Control: no-cache
+func parseCacheControl(header http.Header) map[string]string {
+	directives := map[string]string{}
+	for _, value := range header.Values("Cache-Control") {
+		for _, directive := range strings.Split(value, ",") {
+			name, arg, _ := strings.Cut(strings.TrimSpace(directive), "=")
+			if name == "" {
+				continue
+			}
+			directives[strings.ToLower(name)] = strings.Trim(arg, `"`)
+		}
+	}
+	if _, ok := directives["no-cache"]; !ok && len(header.Values("Cache-Control")) == 0 &&
+		strings.EqualFold(header.Get("Pragma"), "no-cache") {
+		directives["no-cache"] = ""
+	}
+	return directives
+}
+
+// directiveSeconds returns the duration of a delta-seconds directive, e.g. max-age=60
+func directiveSeconds(directives map[string]string, name string) (time.Duration, bool) {
+	value, ok := directives[name]
+	if !ok {
+		return 0, false
+	}
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || seconds < 0 {
+		return 0, true
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+// varyHeaders returns the canonical names of the request headers listed by Vary
+func varyHeaders(header http.Header) []string {
+	var names []string
+	for _, value := range header.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+	return names
+}
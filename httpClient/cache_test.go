@@ -0,0 +1,371 @@
+package httpClient
+
+import (
+	"context"
+	"io"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/embedded"
+	sdkTrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// testCache client whose cache transport runs on a fake clock advanced with advance
+type testCache struct {
+	client    *http.Client
+	transport *cacheTransport
+	start     time.Time
+	elapsed   atomic.Int64
+}
+
+func newTestCache(t *testing.T, storage CacheStorage) *testCache {
+	c := &testCache{start: time.Now().Truncate(time.Second)}
+	transport := newCacheTransport(http.DefaultTransport, storage, &HTTPClient{})
+	transport.now = c.now
+	c.client, c.transport = &http.Client{Transport: transport}, transport
+	return c
+}
+
+func (c *testCache) now() time.Time {
+	return c.start.Add(time.Duration(c.elapsed.Load()))
+}
+
+func (c *testCache) advance(d time.Duration) {
+	c.elapsed.Add(int64(d))
+}
+
+// serve returns a test server dating its responses with the fake clock
+func (c *testCache) serve(t *testing.T, handler http.HandlerFunc) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", c.now().UTC().Format(http.TimeFormat))
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// lookups counts the cache lookups recorded by status
+type lookups struct {
+	embedded.Int64Counter
+
+	mu     sync.Mutex
+	counts map[string]int64
+}
+
+func (l *lookups) Add(_ context.Context, incr int64, opts ...metric.AddOption) {
+	attrs := metric.NewAddConfig(opts).Attributes()
+	status, _ := attrs.Value(cacheStatusKey)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.counts[status.AsString()] += incr
+}
+
+func (l *lookups) snapshot() map[string]int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return maps.Clone(l.counts)
+}
+
+func (c *testCache) get(t *testing.T, url string, header http.Header) (*http.Response, string) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	resp, err := c.client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp, string(body)
+}
+
+func TestCacheMaxAgeAndRevalidation(t *testing.T) {
+	var calls atomic.Int32
+	cache := newTestCache(t, NewMemoryCache(10))
+	server := cache.serve(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_, _ = w.Write([]byte("v1"))
+	})
+
+	_, body := cache.get(t, server.URL, nil)
+	assert.Equal(t, "v1", body)
+	assert.Equal(t, int32(1), calls.Load())
+
+	cache.advance(30 * time.Second)
+	resp, body := cache.get(t, server.URL, nil)
+	assert.Equal(t, "v1", body)
+	assert.Equal(t, "30", resp.Header.Get("Age"))
+	assert.Equal(t, int32(1), calls.Load(), "fresh responses are served from the cache")
+
+	_, body = cache.get(t, server.URL, http.Header{"Cache-Control": {"no-cache"}})
+	assert.Equal(t, "v1", body)
+	assert.Equal(t, int32(2), calls.Load(), "request no-cache revalidates")
+
+	cache.advance(61 * time.Second)
+	resp, body = cache.get(t, server.URL, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "v1", body, "the stored body is served on 304")
+	assert.Equal(t, int32(3), calls.Load())
+
+	_, _ = cache.get(t, server.URL, nil)
+	assert.Equal(t, int32(3), calls.Load(), "the 304 refreshed the stored response")
+}
+
+func TestCacheLastModified(t *testing.T) {
+	lastModified := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Format(http.TimeFormat)
+
+	var calls atomic.Int32
+	cache := newTestCache(t, NewMemoryCache(10))
+	server := cache.serve(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Last-Modified", lastModified)
+		if r.Header.Get("If-Modified-Since") == lastModified {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_, _ = w.Write([]byte("body"))
+	})
+
+	_, body := cache.get(t, server.URL, nil)
+	assert.Equal(t, "body", body)
+	_, body = cache.get(t, server.URL, nil)
+	assert.Equal(t, "body", body)
+	assert.Equal(t, int32(2), calls.Load(), "responses without freshness are always revalidated")
+}
+
+func TestCacheNoStoreAndUnsafeMethods(t *testing.T) {
+	var calls atomic.Int32
+	cache := newTestCache(t, NewMemoryCache(10))
+	server := cache.serve(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.URL.Path == "/private" {
+			w.Header().Set("Cache-Control", "no-store")
+		} else {
+			w.Header().Set("Cache-Control", "max-age=60")
+		}
+		_, _ = w.Write([]byte(r.Method))
+	})
+
+	cache.get(t, server.URL+"/private", nil)
+	cache.get(t, server.URL+"/private", nil)
+	assert.Equal(t, int32(2), calls.Load(), "no-store responses are not stored")
+
+	cache.get(t, server.URL+"/items", nil)
+	cache.get(t, server.URL+"/items", nil)
+	assert.Equal(t, int32(3), calls.Load())
+
+	resp, err := cache.client.Post(server.URL+"/items", "text/plain", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, int32(4), calls.Load())
+
+	cache.get(t, server.URL+"/items", nil)
+	assert.Equal(t, int32(5), calls.Load(), "a successful POST invalidates the stored response")
+}
+
+func TestCacheVary(t *testing.T) {
+	var calls atomic.Int32
+	cache := newTestCache(t, NewMemoryCache(10))
+	server := cache.serve(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("Vary", "Accept-Language")
+		_, _ = w.Write([]byte(r.Header.Get("Accept-Language")))
+	})
+
+	_, body := cache.get(t, server.URL, http.Header{"Accept-Language": {"en"}})
+	assert.Equal(t, "en", body)
+	_, body = cache.get(t, server.URL, http.Header{"Accept-Language": {"en"}})
+	assert.Equal(t, "en", body)
+	assert.Equal(t, int32(1), calls.Load())
+
+	_, body = cache.get(t, server.URL, http.Header{"Accept-Language": {"fr"}})
+	assert.Equal(t, "fr", body, "a response stored for other Vary values is not served")
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestCacheStaleWhileRevalidate(t *testing.T) {
+	var version atomic.Int32
+	version.Store(1)
+	cache := newTestCache(t, NewMemoryCache(10))
+	server := cache.serve(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=10, stale-while-revalidate=30")
+		_, _ = w.Write([]byte{byte('0' + version.Load())})
+	})
+
+	_, body := cache.get(t, server.URL, nil)
+	assert.Equal(t, "1", body)
+
+	version.Store(2)
+	cache.advance(20 * time.Second)
+	_, body = cache.get(t, server.URL, nil)
+	assert.Equal(t, "1", body, "the stale response is served while revalidating")
+
+	assert.Eventually(t, func() bool {
+		_, body := cache.get(t, server.URL, nil)
+		return body == "2"
+	}, time.Second, 10*time.Millisecond)
+
+	version.Store(3)
+	cache.advance(time.Minute)
+	_, body = cache.get(t, server.URL, nil)
+	assert.Equal(t, "3", body, "past the stale-while-revalidate window the response is fetched")
+}
+
+func TestCacheStaleWhileRevalidateNotModified(t *testing.T) {
+	var calls atomic.Int32
+	cache := newTestCache(t, NewMemoryCache(10))
+	server := cache.serve(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Cache-Control", "max-age=10, stale-while-revalidate=30")
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_, _ = w.Write([]byte("v1"))
+	})
+
+	cache.get(t, server.URL, nil)
+	cache.advance(20 * time.Second)
+	for i := 0; i < 10; i++ {
+		_, body := cache.get(t, server.URL, nil)
+		assert.Equal(t, "v1", body, "the stale response is served while the 304 refreshes it")
+	}
+
+	assert.Eventually(t, func() bool {
+		resp, _ := cache.get(t, server.URL, nil)
+		return resp.Header.Get("Age") == "0"
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, int32(2), calls.Load(), "the stored response is revalidated once")
+}
+
+func TestCacheBackgroundRevalidationNotRecorded(t *testing.T) {
+	var calls atomic.Int32
+	cache := newTestCache(t, NewMemoryCache(10))
+	recorded := &lookups{counts: map[string]int64{}}
+	cache.transport.requests = recorded
+	server := cache.serve(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Cache-Control", "max-age=10, stale-while-revalidate=30")
+		_, _ = w.Write([]byte("body"))
+	})
+
+	cache.get(t, server.URL, nil)
+	cache.advance(20 * time.Second)
+	cache.get(t, server.URL, nil)
+
+	require.Eventually(t, func() bool {
+		cache.transport.mu.Lock()
+		defer cache.transport.mu.Unlock()
+		return calls.Load() == 2 && len(cache.transport.revalidating) == 0
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, map[string]int64{CacheMiss: 1, CacheStale: 1}, recorded.snapshot(), "every lookup is recorded once")
+}
+
+func TestCacheAuthorization(t *testing.T) {
+	var calls atomic.Int32
+	cache := newTestCache(t, NewMemoryCache(10))
+	server := cache.serve(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.URL.Path == "/public" {
+			w.Header().Set("Cache-Control", "public, max-age=60")
+		} else {
+			w.Header().Set("Cache-Control", "max-age=60")
+		}
+		_, _ = w.Write([]byte(r.Header.Get("Authorization")))
+	})
+
+	_, body := cache.get(t, server.URL+"/private", http.Header{"Authorization": {"Bearer alice"}})
+	assert.Equal(t, "Bearer alice", body)
+	_, body = cache.get(t, server.URL+"/private", http.Header{"Authorization": {"Bearer bob"}})
+	assert.Equal(t, "Bearer bob", body, "the response to an authorized request is not served to another caller")
+	_, body = cache.get(t, server.URL+"/private", nil)
+	assert.Empty(t, body)
+	assert.Equal(t, int32(3), calls.Load())
+
+	cache.get(t, server.URL+"/public", http.Header{"Authorization": {"Bearer alice"}})
+	cache.get(t, server.URL+"/public", http.Header{"Authorization": {"Bearer bob"}})
+	assert.Equal(t, int32(4), calls.Load(), "public responses to authorized requests are stored")
+}
+
+func TestCacheStatusSpanAttribute(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		_, _ = w.Write([]byte("body"))
+	}))
+	t.Cleanup(server.Close)
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdkTrace.NewTracerProvider(sdkTrace.WithSpanProcessor(recorder))
+
+	client := NewHTTPClient(WithTraceEnabled(true), WithTracerProvider(tp), WithCache(NewMemoryCache(10)))
+
+	for i := 0; i < 2; i++ {
+		req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+		require.NoError(t, err)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Contains(t, spans[0].Attributes(), cacheStatusKey.String(CacheMiss))
+	assert.Contains(t, spans[1].Attributes(), cacheStatusKey.String(CacheHit))
+}
+
+func TestMemoryCacheEviction(t *testing.T) {
+	storage := NewMemoryCache(2)
+	storage.Set("a", []byte("1"))
+	storage.Set("b", []byte("2"))
+	storage.Get("a")
+	storage.Set("c", []byte("3"))
+
+	_, ok := storage.Get("b")
+	assert.False(t, ok, "the least recently used entry is evicted")
+	value, ok := storage.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("1"), value)
+
+	storage.Delete("a")
+	_, ok = storage.Get("a")
+	assert.False(t, ok)
+}
+
+func TestDiskCache(t *testing.T) {
+	dir := t.TempDir()
+
+	storage, err := NewDiskCache(dir)
+	require.NoError(t, err)
+	storage.Set("GET https://example.com/a?b=c", []byte("value"))
+
+	reopened, err := NewDiskCache(dir)
+	require.NoError(t, err)
+	value, ok := reopened.Get("GET https://example.com/a?b=c")
+	assert.True(t, ok, "the responses survive restarts")
+	assert.Equal(t, []byte("value"), value)
+
+	reopened.Delete("GET https://example.com/a?b=c")
+	_, ok = storage.Get("GET https://example.com/a?b=c")
+	assert.False(t, ok)
+}
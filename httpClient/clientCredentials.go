@@ -0,0 +1,260 @@
+package httpClient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// default settings of the client credentials token source
+const (
+	defaultRefreshBefore = time.Minute
+	defaultTokenTimeout  = 10 * time.Second
+	minRefreshBackoff    = time.Second      // first delay before retrying a failed background refresh
+	maxRefreshBackoff    = 30 * time.Second // the delay doubles on every failure up to this one
+)
+
+// ClientCredentialsTokenSource OAuth2 client credentials token source caching the token,
+// refreshing it in the background before it expires and fetching it only once under concurrency
+type ClientCredentialsTokenSource struct {
+	TokenURL       string
+	ClientID       string
+	ClientSecret   string
+	Scopes         []string
+	EndpointParams url.Values
+	AuthInBody     bool          // sends the client credentials as form params instead of basic auth
+	RefreshBefore  time.Duration // refreshes the token this long before it expires, at most half of its lifetime
+	Client         *http.Client
+
+	now func() time.Time
+
+	mu        sync.Mutex
+	token     *Token
+	refreshAt time.Time
+	failures  int // consecutive failed refreshes of the cached token
+	flight    *tokenFlight
+}
+
+// tokenFlight a token request shared by every caller waiting for it
+type tokenFlight struct {
+	done  chan struct{}
+	token *Token
+	err   error
+}
+
+// ClientCredentialsOption different options to configure the ClientCredentialsTokenSource
+type ClientCredentialsOption func(source *ClientCredentialsTokenSource)
+
+// WithScopes requests the scopes for the token
+func WithScopes(scopes ...string) ClientCredentialsOption {
+	return func(source *ClientCredentialsTokenSource) {
+		source.Scopes = append(source.Scopes, scopes...)
+	}
+}
+
+// WithEndpointParams sends additional form params to the token endpoint, e.g. audience
+func WithEndpointParams(params url.Values) ClientCredentialsOption {
+	return func(source *ClientCredentialsTokenSource) {
+		source.EndpointParams = params
+	}
+}
+
+// WithAuthInBody sends the client id and secret as form params instead of basic auth
+func WithAuthInBody() ClientCredentialsOption {
+	return func(source *ClientCredentialsTokenSource) {
+		source.AuthInBody = true
+	}
+}
+
+// WithRefreshBefore refreshes the token in the background this long before it expires, 1 minute by default
+func WithRefreshBefore(d time.Duration) ClientCredentialsOption {
+	return func(source *ClientCredentialsTokenSource) {
+		source.RefreshBefore = d
+	}
+}
+
+// WithTokenClient uses c to call the token endpoint, e.g. for mTLS, a client with a 10 seconds timeout by default
+func WithTokenClient(c *http.Client) ClientCredentialsOption {
+	return func(source *ClientCredentialsTokenSource) {
+		source.Client = c
+	}
+}
+
+// ClientCredentials creates a TokenSource fetching the tokens from the OAuth2 token endpoint with the client credentials grant
+func ClientCredentials(tokenURL, clientID, clientSecret string, opts ...ClientCredentialsOption) *ClientCredentialsTokenSource {
+	source := &ClientCredentialsTokenSource{
+		TokenURL:      tokenURL,
+		ClientID:      clientID,
+		ClientSecret:  clientSecret,
+		RefreshBefore: defaultRefreshBefore,
+		Client:        &http.Client{Timeout: defaultTokenTimeout},
+		now:           time.Now,
+	}
+	for _, opt := range opts {
+		opt(source)
+	}
+	return source
+}
+
+// Token returns the cached token, a token about to expire is refreshed in the background while an expired one is fetched
+// once for every waiting caller. A caller whose ctx is done stops waiting without cancelling the fetch of the others.
+// A failed background refresh is retried with an exponential backoff while the cached token is still valid
+func (s *ClientCredentialsTokenSource) Token(ctx context.Context) (*Token, error) {
+	s.mu.Lock()
+	now := s.now()
+	if s.token != nil && (s.token.Expiry.IsZero() || now.Before(s.token.Expiry)) {
+		token := s.token
+		if !s.refreshAt.IsZero() && !now.Before(s.refreshAt) {
+			s.startFlight()
+		}
+		s.mu.Unlock()
+		return token, nil
+	}
+	flight := s.startFlight()
+	s.mu.Unlock()
+
+	select {
+	case <-flight.done:
+		return flight.token, flight.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Invalidate drops the cached token if it is still token, the next call of Token fetches a new one
+func (s *ClientCredentialsTokenSource) Invalidate(token *Token) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token == token {
+		s.token = nil
+		s.refreshAt = time.Time{}
+	}
+}
+
+// startFlight returns the in-flight token request or starts a new one, s.mu must be held
+func (s *ClientCredentialsTokenSource) startFlight() *tokenFlight {
+	if s.flight != nil {
+		return s.flight
+	}
+
+	flight := &tokenFlight{done: make(chan struct{})}
+	s.flight = flight
+
+	go func() {
+		token, err := s.fetch(context.Background())
+
+		s.mu.Lock()
+		if err == nil {
+			s.token = token
+			s.refreshAt = s.refreshTime(token)
+			s.failures = 0
+		} else if s.token != nil && !s.refreshAt.IsZero() {
+			s.refreshAt = s.retryTime()
+			s.failures++
+		}
+		s.flight = nil
+		s.mu.Unlock()
+
+		flight.token, flight.err = token, err
+		close(flight.done)
+	}()
+
+	return flight
+}
+
+// refreshTime returns when the token is refreshed in the background, zero if it never expires
+func (s *ClientCredentialsTokenSource) refreshTime(token *Token) time.Time {
+	if token.Expiry.IsZero() {
+		return time.Time{}
+	}
+	before := s.RefreshBefore
+	if lifetime := token.Expiry.Sub(s.now()); before > lifetime/2 {
+		before = lifetime / 2
+	}
+	return token.Expiry.Add(-before)
+}
+
+// retryTime returns when a failed refresh of the cached token is retried, never after it expires, s.mu must be held
+func (s *ClientCredentialsTokenSource) retryTime() time.Time {
+	backoff := maxRefreshBackoff
+	if s.failures < 5 {
+		backoff = min(minRefreshBackoff<<s.failures, maxRefreshBackoff)
+	}
+	now := s.now()
+	if untilExpiry := s.token.Expiry.Sub(now); untilExpiry < backoff {
+		backoff = untilExpiry
+	}
+	return now.Add(backoff)
+}
+
+// tokenResponse successful and error response of the token endpoint, RFC 6749 section 5
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int64  `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// fetch requests a new token from the token endpoint
+func (s *ClientCredentialsTokenSource) fetch(ctx context.Context) (*Token, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(s.Scopes) != 0 {
+		form.Set("scope", strings.Join(s.Scopes, " "))
+	}
+	for key, values := range s.EndpointParams {
+		form[key] = values
+	}
+	if s.AuthInBody {
+		form.Set("client_id", s.ClientID)
+		form.Set("client_secret", s.ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if !s.AuthInBody {
+		req.SetBasicAuth(url.QueryEscape(s.ClientID), url.QueryEscape(s.ClientSecret))
+	}
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token response: %w", err)
+	}
+
+	var tr tokenResponse
+	if err := json.Unmarshal(body, &tr); err != nil && resp.StatusCode < 300 {
+		return nil, fmt.Errorf("failed to decode token response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 || tr.Error != "" {
+		return nil, fmt.Errorf("token endpoint returned %d: %s %s", resp.StatusCode, tr.Error, tr.ErrorDescription)
+	}
+	if tr.AccessToken == "" {
+		return nil, fmt.Errorf("token endpoint returned no access_token")
+	}
+
+	token := &Token{AccessToken: tr.AccessToken, TokenType: tr.TokenType}
+	if strings.EqualFold(token.TokenType, "bearer") {
+		token.TokenType = "Bearer"
+	}
+	if tr.ExpiresIn > 0 {
+		token.Expiry = s.now().Add(time.Duration(tr.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
@@ -0,0 +1,157 @@
+package httpClient
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// coalescedKey span attribute set on the requests which joined an in-flight identical request
+const coalescedKey = attribute.Key("http.request.coalesced")
+
+// Coalescer collapses the concurrent identical GETs of a client into one upstream call, see WithCoalescing
+type Coalescer struct {
+	headers []string // canonical names of the request headers which are part of the key
+
+	mu    sync.Mutex
+	calls map[string]*coalescedCall
+}
+
+// coalescedCall upstream call shared by the callers of the same key
+type coalescedCall struct {
+	done    chan struct{}
+	cancel  context.CancelFunc
+	waiters int // callers still waiting for the call, guarded by Coalescer.mu
+
+	resp *http.Response // its body is read into body, the response is never handed out as is
+	body []byte
+	err  error
+}
+
+// identityHeaders request headers always part of the key, the callers of different users never share a response
+var identityHeaders = []string{"Authorization", "Cookie"}
+
+// NewCoalescer creates a Coalescer keying the requests on method, URL, Authorization, Cookie and the values of the headers, e.g. Accept
+func NewCoalescer(headers ...string) *Coalescer {
+	canonical := append([]string(nil), identityHeaders...)
+	for _, header := range headers {
+		if header = http.CanonicalHeaderKey(header); !slices.Contains(canonical, header) {
+			canonical = append(canonical, header)
+		}
+	}
+	return &Coalescer{headers: canonical, calls: map[string]*coalescedCall{}}
+}
+
+// WithCoalescing collapses the in-flight identical GETs of the client, keyed on method, URL, Authorization, Cookie and the values of the headers,
+// into one upstream call whose response is shared with every caller, see NewCoalescer
+func WithCoalescing(headers ...string) HTTPClientOption {
+	return func(client *HTTPClient) {
+		client.Coalescer = NewCoalescer(headers...)
+	}
+}
+
+// key returns the key of the request, the header values are joined as the request could send them
+func (c *Coalescer) key(request *http.Request) string {
+	var key strings.Builder
+	key.WriteString(request.Method + " " + request.URL.String())
+	for _, header := range c.headers {
+		key.WriteString("\n" + header + ": " + strings.Join(request.Header.Values(header), ","))
+	}
+	return key.String()
+}
+
+// do sends the request with next unless an identical one is in flight, every caller gets its own copy of the response.
+// The upstream call is detached from the cancellation and deadline of the caller which started it, a caller giving up only stops
+// waiting and the upstream call is canceled once every caller gave up
+func (c *Coalescer) do(request *http.Request, next RoundTripFunc) (*http.Response, error) {
+	if request.Method != http.MethodGet || (request.Body != nil && request.Body != http.NoBody) {
+		return next(request)
+	}
+
+	key := c.key(request)
+
+	c.mu.Lock()
+	call, inFlight := c.calls[key]
+	if inFlight {
+		call.waiters++
+	} else {
+		ctx, cancel := context.WithCancel(context.WithoutCancel(request.Context()))
+		call = &coalescedCall{done: make(chan struct{}), cancel: cancel, waiters: 1}
+		c.calls[key] = call
+		go c.run(key, call, request.Clone(ctx), next)
+	}
+	c.mu.Unlock()
+
+	if inFlight {
+		trace.SpanFromContext(request.Context()).SetAttributes(coalescedKey.Bool(true))
+	}
+
+	select {
+	case <-call.done:
+		return call.response(request)
+	case <-request.Context().Done():
+		c.leave(key, call)
+		return nil, request.Context().Err()
+	}
+}
+
+// run sends the shared request and reads its body, the call is forgotten once done so that later requests are sent again
+func (c *Coalescer) run(key string, call *coalescedCall, request *http.Request, next RoundTripFunc) {
+	defer call.cancel()
+
+	call.resp, call.err = next(request)
+	if call.err == nil {
+		call.body, call.err = io.ReadAll(call.resp.Body)
+		call.resp.Body.Close()
+		if call.err != nil {
+			call.err = fmt.Errorf("failed to read response body: %w", call.err)
+		}
+	}
+
+	c.mu.Lock()
+	c.forget(key, call)
+	c.mu.Unlock()
+	close(call.done)
+}
+
+// leave removes a canceled caller, the upstream call is canceled when it was the last one
+func (c *Coalescer) leave(key string, call *coalescedCall) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	call.waiters--
+	if call.waiters == 0 {
+		c.forget(key, call)
+		call.cancel()
+	}
+}
+
+// forget removes the call of the key unless a newer call replaced it, c.mu must be held
+func (c *Coalescer) forget(key string, call *coalescedCall) {
+	if c.calls[key] == call {
+		delete(c.calls, key)
+	}
+}
+
+// response returns a copy of the shared response for the caller's request, with its own headers and body reader
+func (call *coalescedCall) response(request *http.Request) (*http.Response, error) {
+	if call.err != nil {
+		return nil, call.err
+	}
+
+	resp := *call.resp
+	resp.Header = call.resp.Header.Clone()
+	resp.Trailer = call.resp.Trailer.Clone()
+	resp.Body = io.NopCloser(bytes.NewReader(call.body))
+	resp.ContentLength = int64(len(call.body))
+	resp.Request = request
+	return &resp, nil
+}
@@ -0,0 +1,264 @@
+package httpClient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// waiters returns the number of callers waiting for the in-flight calls
+func (c *Coalescer) waiters() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	n := 0
+	for _, call := range c.calls {
+		n += call.waiters
+	}
+	return n
+}
+
+func TestCoalescing(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		<-release
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(r.Header.Get("Accept")))
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewHTTPClient(WithCoalescing("Accept"))
+
+	const callers = 10
+	var wg sync.WaitGroup
+	bodies := make([]string, callers)
+	responses := make([]*http.Response, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			req, err := http.NewRequest(http.MethodGet, server.URL+"/items", nil)
+			if !assert.NoError(t, err) {
+				return
+			}
+			req.Header.Set("Accept", "application/json")
+
+			resp, err := client.Do(req)
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			assert.NoError(t, err)
+			bodies[i], responses[i] = string(body), resp
+		}(i)
+	}
+
+	require.Eventually(t, func() bool { return client.Coalescer.waiters() == callers }, time.Second, time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load(), "the identical GETs share one upstream call")
+	for i := 0; i < callers; i++ {
+		assert.Equal(t, "application/json", bodies[i], "every caller reads the whole body")
+	}
+	responses[0].Header.Set("Content-Type", "text/plain")
+	assert.Equal(t, "application/json", responses[1].Header.Get("Content-Type"), "every caller gets its own headers")
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/items", nil)
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, int32(2), calls.Load(), "completed calls are not reused")
+}
+
+func TestCoalescingKey(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		<-release
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewHTTPClient(WithCoalescing())
+
+	requests := []struct {
+		method, path, token, cookie string
+	}{
+		{http.MethodGet, "/a", "alice", ""},
+		{http.MethodGet, "/a", "bob", ""},
+		{http.MethodGet, "/a", "alice", "session=1"},
+		{http.MethodGet, "/a", "alice", "session=2"},
+		{http.MethodGet, "/b", "alice", ""},
+		{http.MethodPost, "/a", "alice", ""},
+		{http.MethodPost, "/a", "alice", ""},
+	}
+
+	var wg sync.WaitGroup
+	for _, r := range requests {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var body io.Reader
+			if r.method == http.MethodPost {
+				body = strings.NewReader("{}")
+			}
+			req, err := http.NewRequest(r.method, server.URL+r.path, body)
+			if !assert.NoError(t, err) {
+				return
+			}
+			req.Header.Set("Authorization", "Bearer "+r.token)
+			if r.cookie != "" {
+				req.Header.Set("Cookie", r.cookie)
+			}
+
+			resp, err := client.Do(req)
+			if assert.NoError(t, err) {
+				resp.Body.Close()
+			}
+		}()
+	}
+
+	require.Eventually(t, func() bool { return calls.Load() == int32(len(requests)) }, time.Second, time.Millisecond,
+		"requests differing in method, URL, Authorization or Cookie are not coalesced")
+	close(release)
+	wg.Wait()
+}
+
+func TestCoalescingCancellation(t *testing.T) {
+	canceled := make(chan struct{})
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+			_, _ = w.Write([]byte("ok"))
+		case <-r.Context().Done():
+			close(canceled)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewHTTPClient(WithCoalescing())
+
+	get := func(ctx context.Context) (string, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+		if err != nil {
+			return "", err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		return string(body), err
+	}
+
+	t.Run("a canceled caller does not cancel the others", func(t *testing.T) {
+		leaderCtx, cancelLeader := context.WithCancel(context.Background())
+		defer cancelLeader()
+
+		leader := make(chan error, 1)
+		go func() {
+			_, err := get(leaderCtx)
+			leader <- err
+		}()
+		require.Eventually(t, func() bool { return client.Coalescer.waiters() == 1 }, time.Second, time.Millisecond)
+
+		waiter := make(chan string, 1)
+		go func() {
+			body, err := get(context.Background())
+			assert.NoError(t, err)
+			waiter <- body
+		}()
+		require.Eventually(t, func() bool { return client.Coalescer.waiters() == 2 }, time.Second, time.Millisecond)
+
+		cancelLeader()
+		assert.ErrorIs(t, <-leader, context.Canceled)
+
+		close(release)
+		assert.Equal(t, "ok", <-waiter)
+	})
+
+	t.Run("the upstream call is canceled once every caller gave up", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		release = make(chan struct{})
+		_, err := get(ctx)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+		select {
+		case <-canceled:
+		case <-time.After(time.Second):
+			t.Fatal("the upstream call was not canceled")
+		}
+		assert.Zero(t, client.Coalescer.waiters())
+	})
+}
+
+func TestCoalescingDeadline(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		_, _ = w.Write([]byte("ok"))
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewHTTPClient(WithCoalescing())
+	get := func(ctx context.Context) (string, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+		if err != nil {
+			return "", err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		return string(body), err
+	}
+
+	leaderCtx, cancelLeader := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancelLeader()
+	leader := make(chan error, 1)
+	go func() {
+		_, err := get(leaderCtx)
+		leader <- err
+	}()
+	require.Eventually(t, func() bool { return client.Coalescer.waiters() == 1 }, time.Second, time.Millisecond)
+
+	waiterCtx, cancelWaiter := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelWaiter()
+	type result struct {
+		body string
+		err  error
+	}
+	waiter := make(chan result, 1)
+	go func() {
+		body, err := get(waiterCtx)
+		waiter <- result{body, err}
+	}()
+	require.Eventually(t, func() bool { return client.Coalescer.waiters() == 2 }, time.Second, time.Millisecond)
+
+	assert.ErrorIs(t, <-leader, context.DeadlineExceeded)
+	close(release)
+
+	res := <-waiter
+	assert.NoError(t, res.err, "the deadline of the caller which started the call does not fail the others")
+	assert.Equal(t, "ok", res.body)
+}
@@ -0,0 +1,311 @@
+package httpClient
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Secret returns the current value of a secret, see StaticSecret, EnvSecret and FileSecret
+type Secret func() (string, error)
+
+// StaticSecret returns a Secret always returning value
+func StaticSecret(value string) Secret {
+	return func() (string, error) {
+		return value, nil
+	}
+}
+
+// EnvSecret returns a Secret reading the environment variable on every request
+func EnvSecret(name string) Secret {
+	return func() (string, error) {
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		return value, nil
+	}
+}
+
+// FileSecret returns a Secret reading the file, trimmed of surrounding whitespace, and reloading it when the file is modified
+func FileSecret(path string) Secret {
+	loader := &secretFileLoader{path: path}
+	return loader.load
+}
+
+// secretFileLoader caches the content of the secret file until it is modified
+type secretFileLoader struct {
+	path string
+
+	mu      sync.Mutex
+	value   string
+	modTime time.Time
+	loaded  bool
+}
+
+func (l *secretFileLoader) load() (string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	info, err := os.Stat(l.path)
+	if err == nil && l.loaded && info.ModTime().Equal(l.modTime) {
+		return l.value, nil
+	}
+
+	b, readErr := os.ReadFile(l.path)
+	if err != nil || readErr != nil {
+		// keep serving the previous secret while the file is being rotated
+		if l.loaded {
+			return l.value, nil
+		}
+		if err == nil {
+			err = readErr
+		}
+		return "", fmt.Errorf("failed to read secret file: %w", err)
+	}
+
+	l.value = strings.TrimSpace(string(b))
+	l.modTime = info.ModTime()
+	l.loaded = true
+	return l.value, nil
+}
+
+// Credential authenticates a request sent to a host of the CredentialStore, the request is a copy owned by the credential.
+// It is applied before the request is signed and again on every redirect to a host of the store, hence it must keep
+// the headers and query params the request already has
+type Credential interface {
+	Apply(request *http.Request) error
+}
+
+// CredentialFunc adapts a function to a Credential
+type CredentialFunc func(request *http.Request) error
+
+func (f CredentialFunc) Apply(request *http.Request) error {
+	return f(request)
+}
+
+// BasicAuth returns a Credential sending the username and password as basic auth
+func BasicAuth(username string, password Secret) Credential {
+	return CredentialFunc(func(request *http.Request) error {
+		if request.Header.Get("Authorization") != "" {
+			return nil
+		}
+		value, err := password()
+		if err != nil {
+			return err
+		}
+		request.SetBasicAuth(username, value)
+		return nil
+	})
+}
+
+// BearerToken returns a Credential sending the token as "Authorization: Bearer <token>"
+func BearerToken(token Secret) Credential {
+	return APIKeyHeader("Authorization", func() (string, error) {
+		value, err := token()
+		if err != nil {
+			return "", err
+		}
+		return "Bearer " + value, nil
+	})
+}
+
+// APIKeyHeader returns a Credential sending the key in the header, e.g. X-API-Key
+func APIKeyHeader(header string, key Secret) Credential {
+	return CredentialFunc(func(request *http.Request) error {
+		if request.Header.Get(header) != "" {
+			return nil
+		}
+		value, err := key()
+		if err != nil {
+			return err
+		}
+		request.Header.Set(header, value)
+		return nil
+	})
+}
+
+// APIKeyQuery returns a Credential sending the key as the query param, e.g. api_key
+func APIKeyQuery(param string, key Secret) Credential {
+	return CredentialFunc(func(request *http.Request) error {
+		query := request.URL.Query()
+		if query.Has(param) {
+			return nil
+		}
+		value, err := key()
+		if err != nil {
+			return err
+		}
+		query.Set(param, value)
+
+		u := *request.URL
+		u.RawQuery = query.Encode()
+		request.URL = &u
+		return nil
+	})
+}
+
+// hostCredential credential of the hosts matching the pattern
+type hostCredential struct {
+	pattern    string
+	credential Credential
+}
+
+// CredentialStore maps host patterns to credentials, safe for concurrent use
+type CredentialStore struct {
+	mu          sync.RWMutex
+	credentials []hostCredential
+}
+
+// NewCredentialStore creates an empty CredentialStore
+func NewCredentialStore() *CredentialStore {
+	return &CredentialStore{}
+}
+
+// Add registers the credential of the hosts matching the pattern: "api.example.com" matches the host on any port,
+// "api.example.com:8443" only on that port and "*.example.com" every subdomain but not example.com itself.
+// The first added pattern matching the host wins
+func (s *CredentialStore) Add(pattern string, credential Credential) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.credentials = append(s.credentials, hostCredential{pattern: strings.ToLower(pattern), credential: credential})
+}
+
+// lookup returns the credential of the first pattern matching the host of the URL
+func (s *CredentialStore) lookup(host string) Credential {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	host = strings.ToLower(host)
+	hostname := host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		hostname = h
+	}
+
+	for _, c := range s.credentials {
+		switch {
+		case c.pattern == host || c.pattern == hostname:
+			return c.credential
+		case strings.HasPrefix(c.pattern, "*.") && strings.HasSuffix(hostname, c.pattern[1:]):
+			return c.credential
+		}
+	}
+	return nil
+}
+
+// WithCredentials authenticates the requests sent to the hosts matching the pattern with the credential, see CredentialStore.Add
+func WithCredentials(pattern string, credential Credential) HTTPClientOption {
+	return func(client *HTTPClient) {
+		if client.Credentials == nil {
+			client.Credentials = NewCredentialStore()
+		}
+		client.Credentials.Add(pattern, credential)
+	}
+}
+
+// WithCredentialStore authenticates the requests with the credentials of the store, it can be shared by several clients
+func WithCredentialStore(store *CredentialStore) HTTPClientOption {
+	return func(client *HTTPClient) {
+		client.Credentials = store
+	}
+}
+
+// appliedCredentialsKey context key of the credentials applied to the request before it was signed
+type appliedCredentialsKey struct{}
+
+// appliedCredentials records the credentials applied to the request of the caller, so that the transport does not apply them
+// again and removes their headers from the redirects to other hosts, and the debug dumps redact them
+type appliedCredentials struct {
+	host    string
+	url     string
+	headers []string // headers added by the credential
+	params  []string // query params added by the credential
+}
+
+// applyCredentials applies the credentials of the request host to a copy of every request, before it is signed
+func (c *HTTPClient) applyCredentials(next RoundTripFunc) RoundTripFunc {
+	return func(request *http.Request) (*http.Response, error) {
+		credential := c.Credentials.lookup(request.URL.Host)
+		if credential == nil {
+			return next(request)
+		}
+
+		authenticated, err := applyCredential(request, credential)
+		if err != nil {
+			return nil, err
+		}
+
+		applied := appliedCredentials{host: authenticated.URL.Host, url: authenticated.URL.String()}
+		for name := range authenticated.Header {
+			if _, ok := request.Header[name]; !ok {
+				applied.headers = append(applied.headers, name)
+			}
+		}
+		query := request.URL.Query()
+		for name := range authenticated.URL.Query() {
+			if !query.Has(name) {
+				applied.params = append(applied.params, name)
+			}
+		}
+		ctx := context.WithValue(authenticated.Context(), appliedCredentialsKey{}, applied)
+		return next(authenticated.WithContext(ctx))
+	}
+}
+
+// applyCredential returns a copy of the request authenticated with the credential
+func applyCredential(request *http.Request, credential Credential) (*http.Request, error) {
+	authenticated := request.Clone(request.Context())
+	if authenticated.Header == nil {
+		authenticated.Header = http.Header{}
+	}
+	if err := credential.Apply(authenticated); err != nil {
+		return nil, fmt.Errorf("failed to apply credentials of %s: %w", request.URL.Host, err)
+	}
+	return authenticated, nil
+}
+
+// credentialsTransport applies the credentials of the host of every redirect right before it is sent, the request of the
+// caller being already authenticated by HTTPClient.applyCredentials. The headers of those credentials are removed from the
+// redirects to other hosts, hence the redirects to other hosts never carry them
+type credentialsTransport struct {
+	next  http.RoundTripper
+	store *CredentialStore
+}
+
+func (t credentialsTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	applied, _ := request.Context().Value(appliedCredentialsKey{}).(appliedCredentials)
+	if applied.url == request.URL.String() {
+		return t.next.RoundTrip(request)
+	}
+
+	credential := t.store.lookup(request.URL.Host)
+	foreign := applied.host != request.URL.Host && len(applied.headers) > 0
+	if credential == nil && !foreign {
+		return t.next.RoundTrip(request)
+	}
+
+	redirect := request.Clone(request.Context())
+	if foreign {
+		for _, name := range applied.headers {
+			redirect.Header.Del(name)
+		}
+	}
+	if credential == nil {
+		return t.next.RoundTrip(redirect)
+	}
+
+	authenticated, err := applyCredential(redirect, credential)
+	if err != nil {
+		if request.Body != nil {
+			request.Body.Close()
+		}
+		return nil, err
+	}
+	return t.next.RoundTrip(authenticated)
+}
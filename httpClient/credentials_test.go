@@ -0,0 +1,173 @@
+package httpClient
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCredentialStoreLookup(t *testing.T) {
+	basic := BasicAuth("user", StaticSecret("pass"))
+	key := APIKeyHeader("X-API-Key", StaticSecret("key"))
+	bearer := BearerToken(StaticSecret("token"))
+
+	store := NewCredentialStore()
+	store.Add("api.example.com:8443", basic)
+	store.Add("API.example.com", key)
+	store.Add("*.partner.io", bearer)
+
+	assert.NotNil(t, store.lookup("api.example.com:8443"))
+	assert.NotNil(t, store.lookup("api.example.com"))
+	assert.NotNil(t, store.lookup("api.example.com:443"))
+	assert.NotNil(t, store.lookup("eu.partner.io"))
+	assert.Nil(t, store.lookup("partner.io"), "wildcards match only subdomains")
+	assert.Nil(t, store.lookup("evilpartner.io"))
+	assert.Nil(t, store.lookup("api.example.com.evil.io"))
+	assert.Nil(t, store.lookup("example.com"))
+}
+
+func TestCredentialsDoNotLeakOnRedirect(t *testing.T) {
+	foreign := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get("Authorization"))
+		assert.Empty(t, r.Header.Get("X-API-Key"))
+		assert.Empty(t, r.URL.Query().Get("api_key"))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(foreign.Close)
+
+	trusted := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "user", username)
+		assert.Equal(t, "pass", password)
+		assert.Equal(t, "key", r.Header.Get("X-API-Key"))
+		assert.Equal(t, "query-key", r.URL.Query().Get("api_key"))
+
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, foreign.URL+"/landing", http.StatusFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(trusted.Close)
+
+	trustedHost := strings.TrimPrefix(trusted.URL, "http://")
+	client := NewHTTPClient(
+		WithCredentials(trustedHost, CredentialFunc(func(request *http.Request) error {
+			for _, c := range []Credential{
+				BasicAuth("user", StaticSecret("pass")),
+				APIKeyHeader("X-API-Key", StaticSecret("key")),
+				APIKeyQuery("api_key", StaticSecret("query-key")),
+			} {
+				if err := c.Apply(request); err != nil {
+					return err
+				}
+			}
+			return nil
+		})),
+	)
+
+	for _, path := range []string{"/direct", "/redirect"} {
+		req, err := http.NewRequest(http.MethodGet, trusted.URL+path, nil)
+		require.NoError(t, err)
+
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		assert.Empty(t, req.Header.Get("Authorization"), "the caller's request is not mutated")
+	}
+
+	req, err := http.NewRequest(http.MethodGet, foreign.URL, nil)
+	require.NoError(t, err)
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+}
+
+func TestCredentialsAppliedBeforeSigning(t *testing.T) {
+	secret := []byte("shared-secret")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "query-key", r.URL.Query().Get("api_key"))
+		assert.Equal(t, "key", r.Header.Get("X-API-Key"))
+
+		bodyHash := sha256.Sum256(nil)
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(r.Method + "\n" + r.URL.RequestURI() + "\n" + hex.EncodeToString(bodyHash[:]) + "\n" + r.Header.Get("X-Timestamp")))
+		assert.Equal(t, hex.EncodeToString(mac.Sum(nil)), r.Header.Get("X-Signature"), "the signature covers the credentials")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+
+	var signedHeaders http.Header
+	signer := NewHMACSigner("partner-1", secret)
+	client := NewHTTPClient(
+		WithCredentials(strings.TrimPrefix(server.URL, "http://"), CredentialFunc(func(request *http.Request) error {
+			if err := APIKeyQuery("api_key", StaticSecret("query-key")).Apply(request); err != nil {
+				return err
+			}
+			return APIKeyHeader("X-API-Key", StaticSecret("key")).Apply(request)
+		})),
+		WithSigner(SignerFunc(func(request *http.Request, body []byte) error {
+			signedHeaders = request.Header.Clone()
+			return signer.Sign(request, body)
+		})),
+	)
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/orders?status=open", nil)
+	require.NoError(t, err)
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	assert.Equal(t, "key", signedHeaders.Get("X-API-Key"), "the signer sees the credentials")
+	assert.Empty(t, req.URL.Query().Get("api_key"), "the caller's request is not mutated")
+}
+
+func TestSecrets(t *testing.T) {
+	t.Setenv("RUSTIC_TEST_API_KEY", "from-env")
+
+	value, err := EnvSecret("RUSTIC_TEST_API_KEY")()
+	require.NoError(t, err)
+	assert.Equal(t, "from-env", value)
+
+	_, err = EnvSecret("RUSTIC_TEST_UNSET")()
+	assert.ErrorContains(t, err, "RUSTIC_TEST_UNSET is not set")
+
+	path := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(path, []byte("first\n"), 0o600))
+
+	secret := FileSecret(path)
+	value, err = secret()
+	require.NoError(t, err)
+	assert.Equal(t, "first", value)
+
+	require.NoError(t, os.WriteFile(path, []byte("second\n"), 0o600))
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Minute)))
+	value, err = secret()
+	require.NoError(t, err)
+	assert.Equal(t, "second", value, "the file is reloaded once modified")
+
+	require.NoError(t, os.Remove(path))
+	value, err = secret()
+	require.NoError(t, err)
+	assert.Equal(t, "second", value, "the previous secret is kept while the file is rotated")
+
+	_, err = FileSecret(filepath.Join(t.TempDir(), "missing"))()
+	assert.ErrorContains(t, err, "failed to read secret file")
+}
@@ -0,0 +1,234 @@
+package httpClient
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DebugConfig different configurations of the debug dumps, the secrets are redacted as in the logs, see NewDebugConfig
+type DebugConfig struct {
+	Writer            io.Writer
+	Curl              bool // writes every request as an equivalent curl command
+	Wire              bool // writes every request and response in the HTTP/1.1 wire format
+	RedactHeaders     []string
+	RedactQueryParams []string
+	RedactJSONFields  []string
+
+	mu       sync.Mutex // serializes the writes of concurrent requests
+	once     sync.Once
+	redactor *redactor
+}
+
+// DebugOption different options to configure the debug dumps
+type DebugOption func(config *DebugConfig)
+
+// WithCurlDump writes the requests as curl commands, the default along with WithWireDump when no dump is selected
+func WithCurlDump() DebugOption {
+	return func(config *DebugConfig) {
+		config.Curl = true
+	}
+}
+
+// WithWireDump writes the requests and responses in the HTTP/1.1 wire format with httputil
+func WithWireDump() DebugOption {
+	return func(config *DebugConfig) {
+		config.Wire = true
+	}
+}
+
+// WithDebugRedaction redacts the headers, query params and JSON or form fields in addition to the ones redacted from the logs
+func WithDebugRedaction(headers, queryParams, jsonFields []string) DebugOption {
+	return func(config *DebugConfig) {
+		config.RedactHeaders = append(config.RedactHeaders, headers...)
+		config.RedactQueryParams = append(config.RedactQueryParams, queryParams...)
+		config.RedactJSONFields = append(config.RedactJSONFields, jsonFields...)
+	}
+}
+
+// NewDebugConfig creates a DebugConfig writing to w, both the curl and wire dumps are written if none is selected
+func NewDebugConfig(w io.Writer, opts ...DebugOption) *DebugConfig {
+	config := &DebugConfig{
+		Writer:            w,
+		RedactHeaders:     append([]string(nil), defaultRedactedHeaders...),
+		RedactQueryParams: append([]string(nil), defaultRedactedQueryParams...),
+		RedactJSONFields:  append([]string(nil), defaultRedactedJSONFields...),
+	}
+	for _, opt := range opts {
+		opt(config)
+	}
+	if !config.Curl && !config.Wire {
+		config.Curl, config.Wire = true, true
+	}
+	return config
+}
+
+// WithDebug dumps every request of the client to w, see NewDebugConfig
+func WithDebug(w io.Writer, opts ...DebugOption) HTTPClientOption {
+	return func(client *HTTPClient) {
+		client.Debug = NewDebugConfig(w, opts...)
+	}
+}
+
+// debugKey context key of the per-call DebugConfig
+type debugKey struct{}
+
+// ContextWithDebug dumps the requests made with ctx, overriding the DebugConfig of the client
+func ContextWithDebug(ctx context.Context, config *DebugConfig) context.Context {
+	return context.WithValue(ctx, debugKey{}, config)
+}
+
+// debug dumps every attempt right before it is sent, hence with its token authentication and signature redacted.
+// The per-call DebugConfig of the request context overrides the one of the client
+func (c *HTTPClient) debug(next RoundTripFunc) RoundTripFunc {
+	return func(request *http.Request) (*http.Response, error) {
+		config, ok := request.Context().Value(debugKey{}).(*DebugConfig)
+		if !ok {
+			config = c.Debug
+		}
+		if config == nil {
+			return next(request)
+		}
+		return config.dump(next, request)
+	}
+}
+
+// dump writes the request and its response while sending it with next
+func (d *DebugConfig) dump(next RoundTripFunc, request *http.Request) (*http.Response, error) {
+	request = request.Clone(request.Context())
+	body, err := readBody(request)
+	if err != nil {
+		return nil, err
+	}
+
+	r := d.redactFor(request)
+	var dump bytes.Buffer
+	if d.Curl {
+		dump.WriteString(d.curl(r, request, body))
+		dump.WriteString("\n")
+	}
+	if d.Wire {
+		if b, err := d.dumpRequest(r, request, body); err == nil {
+			dump.Write(b)
+			dump.WriteString("\n")
+		}
+	}
+	d.write(dump.Bytes())
+
+	resp, err := next(request)
+	if err == nil && d.Wire {
+		if b, err := d.dumpResponse(r, resp); err == nil {
+			d.write(append(b, '\n'))
+		}
+	}
+	return resp, err
+}
+
+// redact returns the redactor of the configured secrets
+func (d *DebugConfig) redact() *redactor {
+	d.once.Do(func() {
+		d.redactor = newRedactor(d.RedactHeaders, d.RedactQueryParams, d.RedactJSONFields)
+	})
+	return d.redactor
+}
+
+// redactFor returns the redactor of the request, which redacts as well the headers and query params added by the per host
+// credentials as their names are chosen by the caller, e.g. X-Partner-Token
+func (d *DebugConfig) redactFor(request *http.Request) *redactor {
+	applied, ok := request.Context().Value(appliedCredentialsKey{}).(appliedCredentials)
+	if !ok || len(applied.headers)+len(applied.params) == 0 {
+		return d.redact()
+	}
+	return newRedactor(
+		append(slices.Clone(d.RedactHeaders), applied.headers...),
+		append(slices.Clone(d.RedactQueryParams), applied.params...),
+		d.RedactJSONFields,
+	)
+}
+
+// write writes a dump at once
+func (d *DebugConfig) write(b []byte) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	_, _ = d.Writer.Write(b)
+}
+
+// curl returns the request as an equivalent curl command with the secrets redacted
+func (d *DebugConfig) curl(r *redactor, request *http.Request, body []byte) string {
+	command := []string{"curl", "-X", request.Method, shellQuote(r.url(request.URL))}
+
+	headers := r.headers(request.Header)
+	if request.Host != "" && request.Host != request.URL.Host {
+		headers.Set("Host", request.Host)
+	}
+	keys := make([]string, 0, len(headers))
+	for key := range headers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, value := range headers[key] {
+			command = append(command, "-H", shellQuote(key+": "+value))
+		}
+	}
+
+	if len(body) != 0 {
+		command = append(command, "--data-raw", shellQuote(r.body(body, request.Header.Get("Content-Type"), len(body))))
+	}
+	return strings.Join(command, " ")
+}
+
+// dumpRequest returns the request in the wire format with the secrets redacted
+func (d *DebugConfig) dumpRequest(r *redactor, request *http.Request, body []byte) ([]byte, error) {
+	redactedRequest := request.Clone(request.Context())
+	redactedRequest.Header = r.headers(request.Header)
+
+	u := *request.URL
+	if parsed, err := u.Parse(r.url(request.URL)); err == nil {
+		u = *parsed
+	}
+	redactedRequest.URL = &u
+
+	redactedBody := []byte(r.body(body, request.Header.Get("Content-Type"), len(body)))
+	redactedRequest.Body = io.NopCloser(bytes.NewReader(redactedBody))
+	redactedRequest.ContentLength = int64(len(redactedBody))
+	if len(body) == 0 {
+		redactedRequest.Body = nil
+	}
+
+	return httputil.DumpRequestOut(redactedRequest, true)
+}
+
+// dumpResponse returns the response in the wire format with the secrets redacted, the body of resp is kept readable
+func (d *DebugConfig) dumpResponse(r *redactor, resp *http.Response) ([]byte, error) {
+	var body []byte
+	if resp.Body != nil && resp.Body != http.NoBody {
+		var err error
+		if body, err = io.ReadAll(resp.Body); err != nil {
+			return nil, fmt.Errorf("failed to read response body: %w", err)
+		}
+		resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	redactedResponse := *resp
+	redactedResponse.Header = r.headers(resp.Header)
+	redactedBody := []byte(r.body(body, resp.Header.Get("Content-Type"), len(body)))
+	redactedResponse.Body = io.NopCloser(bytes.NewReader(redactedBody))
+	redactedResponse.ContentLength = int64(len(redactedBody))
+
+	return httputil.DumpResponse(&redactedResponse, true)
+}
+
+// shellQuote quotes s for POSIX shells
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
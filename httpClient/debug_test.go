@@ -0,0 +1,113 @@
+package httpClient
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDebugDump(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, "grant_type=client_credentials&client_secret=s3cr3t", string(body))
+		assert.Equal(t, "Bearer s3cr3t", r.Header.Get("Authorization"))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=s3cr3t")
+		_, _ = w.Write([]byte(`{"access_token":"s3cr3t","expires_in":3600}`))
+	}))
+	t.Cleanup(server.Close)
+
+	var dump bytes.Buffer
+	client := NewHTTPClient(
+		WithDebug(&dump),
+		WithTokenSource(StaticTokenSource("s3cr3t")),
+	)
+
+	req, err := http.NewRequest(http.MethodPost, server.URL+"/token?api_key=s3cr3t&scope=it's",
+		strings.NewReader("grant_type=client_credentials&client_secret=s3cr3t"))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, `{"access_token":"s3cr3t","expires_in":3600}`, string(body), "the response body is still readable")
+
+	out := dump.String()
+	assert.NotContains(t, out, "s3cr3t")
+
+	curl := strings.SplitN(out, "\n", 2)[0]
+	assert.Equal(t, "curl -X POST '"+server.URL+"/token?api_key=REDACTED&scope=it%27s'"+
+		" -H 'Authorization: REDACTED' -H 'Content-Type: application/x-www-form-urlencoded'"+
+		" --data-raw 'client_secret=REDACTED&grant_type=client_credentials'", curl)
+
+	assert.Equal(t, `'it'\''s'`, shellQuote("it's"))
+
+	assert.Contains(t, out, "POST /token?api_key=REDACTED&scope=it%27s HTTP/1.1\r\n")
+	assert.Contains(t, out, "HTTP/1.1 200 OK\r\n")
+	assert.Contains(t, out, "Set-Cookie: REDACTED\r\n")
+	assert.Contains(t, out, `{"access_token":"REDACTED","expires_in":3600}`)
+}
+
+func TestDebugDumpRedactsCredentials(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "s3cr3t", r.Header.Get("X-Partner-Token"))
+		assert.Equal(t, "s3cr3t", r.URL.Query().Get("token"))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+
+	var dump bytes.Buffer
+	client := NewHTTPClient(
+		WithDebug(&dump),
+		WithCredentials(strings.TrimPrefix(server.URL, "http://"), CredentialFunc(func(request *http.Request) error {
+			if err := APIKeyHeader("X-Partner-Token", StaticSecret("s3cr3t")).Apply(request); err != nil {
+				return err
+			}
+			return APIKeyQuery("token", StaticSecret("s3cr3t")).Apply(request)
+		})),
+	)
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/orders?status=open", nil)
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	out := dump.String()
+	assert.NotContains(t, out, "s3cr3t", "the custom named credentials are redacted")
+	assert.Equal(t, "curl -X GET '"+server.URL+"/orders?status=open&token=REDACTED' -H 'X-Partner-Token: REDACTED'",
+		strings.SplitN(out, "\n", 2)[0])
+	assert.Contains(t, out, "GET /orders?status=open&token=REDACTED HTTP/1.1\r\n")
+	assert.Contains(t, out, "X-Partner-Token: REDACTED\r\n")
+}
+
+func TestContextWithDebug(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewHTTPClient()
+
+	var dump bytes.Buffer
+	ctx := ContextWithDebug(context.Background(), NewDebugConfig(&dump, WithCurlDump()))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, "curl -X GET '"+server.URL+"'\n", dump.String(), "only the curl dump is selected")
+}
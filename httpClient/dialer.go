@@ -0,0 +1,34 @@
+package httpClient
+
+import (
+	"context"
+	"net"
+	"strings"
+)
+
+// DialContextFunc dials the connection for the transport, same as http.Transport.DialContext
+type DialContextFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// WithDialContext uses dial to establish the connections instead of the default TCP dialer,
+// the dial and keep-alive timeouts are then up to dial
+func WithDialContext(dial DialContextFunc) HTTPClientOption {
+	return func(client *HTTPClient) {
+		client.TransportConfig.DialContext = dial
+	}
+}
+
+// WithUnixSocket connects every request to the unix socket at target, either unix:///path or /path.
+// URLs keep the http://host/... form, the host only being used for the Host header, e.g. http://docker/v1.43/containers/json.
+// The proxy is never used for unix sockets
+func WithUnixSocket(target string) HTTPClientOption {
+	return func(client *HTTPClient) {
+		client.TransportConfig.UnixSocket = strings.TrimPrefix(target, "unix://")
+	}
+}
+
+// unixSocketDialer ignores the address of the request and dials the unix socket at path
+func unixSocketDialer(dialer *net.Dialer, path string) DialContextFunc {
+	return func(ctx context.Context, _, _ string) (net.Conn, error) {
+		return dialer.DialContext(ctx, "unix", path)
+	}
+}
@@ -0,0 +1,63 @@
+package httpClient
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnixSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "sidecar.sock")
+	lis, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "sidecar", r.Host)
+		assert.Equal(t, "/v1/health", r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	server.Listener = lis
+	server.Start()
+	t.Cleanup(server.Close)
+
+	t.Setenv("HTTP_PROXY", "http://proxy:3128")
+
+	for _, target := range []string{"unix://" + socket, socket} {
+		client := NewHTTPClient(WithUnixSocket(target))
+
+		req, err := http.NewRequest(http.MethodGet, "http://sidecar/v1/health", nil)
+		require.NoError(t, err)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	}
+}
+
+func TestDialContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+
+	var dialed string
+	client := NewHTTPClient(WithoutProxy(), WithDialContext(func(ctx context.Context, network, addr string) (net.Conn, error) {
+		dialed = addr
+		return (&net.Dialer{}).DialContext(ctx, network, server.Listener.Addr().String())
+	}))
+
+	req, err := http.NewRequest(http.MethodGet, "http://service.internal:8080/", nil)
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	assert.Equal(t, "service.internal:8080", dialed)
+}
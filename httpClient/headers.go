@@ -0,0 +1,56 @@
+package httpClient
+
+import "net/http"
+
+// WithDefaultHeaders adds headers sent with every request of the client, a request having the header already keeps its own values
+func WithDefaultHeaders(headers http.Header) HTTPClientOption {
+	return func(client *HTTPClient) {
+		if client.DefaultHeaders == nil {
+			client.DefaultHeaders = http.Header{}
+		}
+		for key, values := range headers {
+			for _, value := range values {
+				client.DefaultHeaders.Add(key, value)
+			}
+		}
+	}
+}
+
+// WithUserAgent sets the default User-Agent of the client instead of Go's one
+func WithUserAgent(userAgent string) HTTPClientOption {
+	return WithDefaultHeaders(http.Header{"User-Agent": {userAgent}})
+}
+
+// WithAccept sets the default Accept media types of the client, each one sent as its own header value
+func WithAccept(mediaTypes ...string) HTTPClientOption {
+	return WithDefaultHeaders(http.Header{"Accept": mediaTypes})
+}
+
+// WithAPIKey sends the static API key in the header, e.g. X-API-Key, with the requests to the hosts matching the pattern.
+// Unlike a default header the key is applied by the credentials transport, hence never forwarded on redirects to other hosts,
+// see WithCredentials and APIKeyHeader
+func WithAPIKey(pattern, header, key string) HTTPClientOption {
+	return WithCredentials(pattern, APIKeyHeader(header, StaticSecret(key)))
+}
+
+// withDefaultHeaders returns the request with the default headers it does not have, the caller's request is not mutated
+func (c *HTTPClient) withDefaultHeaders(request *http.Request) *http.Request {
+	var cloned *http.Request
+	for key, values := range c.DefaultHeaders {
+		if _, ok := request.Header[key]; ok {
+			continue
+		}
+		if cloned == nil {
+			cloned = request.Clone(request.Context())
+			if cloned.Header == nil {
+				cloned.Header = http.Header{}
+			}
+		}
+		cloned.Header[key] = append([]string(nil), values...)
+	}
+
+	if cloned == nil {
+		return request
+	}
+	return cloned
+}
@@ -0,0 +1,67 @@
+package httpClient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultHeaders(t *testing.T) {
+	var received http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewHTTPClient(
+		WithUserAgent("rustic-test/1.0"),
+		WithAccept("application/json", "text/plain"),
+		WithAPIKey("127.0.0.1", "x-api-key", "secret"),
+	)
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	req.Header.Set("X-Api-Key", "per-request")
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, "rustic-test/1.0", received.Get("User-Agent"))
+	assert.Equal(t, []string{"application/json", "text/plain"}, received.Values("Accept"))
+	assert.Equal(t, []string{"per-request"}, received.Values("X-Api-Key"), "the request header wins over the default")
+	assert.Empty(t, req.Header.Get("User-Agent"), "the caller's request is not mutated")
+}
+
+func TestAPIKeyNotForwardedOnRedirect(t *testing.T) {
+	var received http.Header
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(other.Close)
+
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secret", r.Header.Get("X-Api-Key"))
+		http.Redirect(w, r, other.URL, http.StatusFound)
+	}))
+	t.Cleanup(api.Close)
+
+	apiURL, err := url.Parse(api.URL)
+	require.NoError(t, err)
+	client := NewHTTPClient(WithAPIKey(apiURL.Host, "X-API-Key", "secret"))
+
+	req, err := http.NewRequest(http.MethodGet, api.URL, nil)
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	assert.Empty(t, received.Get("X-Api-Key"), "the key is not sent to the host the API redirects to")
+}
@@ -0,0 +1,112 @@
+package httpClient
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"golang.org/x/net/http2"
+)
+
+// HTTP2Config different configurations of HTTP/2
+type HTTP2Config struct {
+	Enabled         bool          // explicitly configures HTTP/2 over TLS, also with custom dialers and TLS configurations
+	H2C             bool          // cleartext HTTP/2 with prior knowledge, every request is sent over HTTP/2 without TLS
+	ReadIdleTimeout time.Duration // a health check ping is sent when no frame is received for this long
+	PingTimeout     time.Duration // the connection is closed when the ping is not answered in time
+}
+
+// WithHTTP2 explicitly configures HTTP/2 over TLS, negotiated through ALPN
+func WithHTTP2() HTTPClientOption {
+	return func(client *HTTPClient) {
+		client.TransportConfig.HTTP2.Enabled = true
+	}
+}
+
+// WithH2C sends every request over cleartext HTTP/2 with prior knowledge, for internal services speaking h2c.
+// https URLs are not supported. The dialer, idle connection timeout, compression, max response header size and health check
+// are applied, while the proxy, the connection limits, the TLS options and the response header and expect continue timeouts
+// are ignored and reported to the otel error handler when set
+func WithH2C() HTTPClientOption {
+	return func(client *HTTPClient) {
+		client.TransportConfig.HTTP2.H2C = true
+	}
+}
+
+// WithHTTP2HealthCheck pings the HTTP/2 connections with no frames received for readIdleTimeout
+// and closes them when the ping is not answered within pingTimeout, to detect dead connections
+func WithHTTP2HealthCheck(readIdleTimeout, pingTimeout time.Duration) HTTPClientOption {
+	return func(client *HTTPClient) {
+		client.TransportConfig.HTTP2.Enabled = true
+		client.TransportConfig.HTTP2.ReadIdleTimeout = readIdleTimeout
+		client.TransportConfig.HTTP2.PingTimeout = pingTimeout
+	}
+}
+
+// configureHTTP2 returns the HTTP/2 round tripper for the transport, the transport itself when HTTP/2 is not configured
+func (c HTTP2Config) configureHTTP2(transport *http.Transport) http.RoundTripper {
+	if c.H2C {
+		dial := transport.DialContext
+		h2 := &http2.Transport{
+			AllowHTTP: true,
+			DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+				return dial(ctx, network, addr)
+			},
+			IdleConnTimeout:    transport.IdleConnTimeout,
+			DisableCompression: transport.DisableCompression,
+			ReadIdleTimeout:    c.ReadIdleTimeout,
+			PingTimeout:        c.PingTimeout,
+		}
+		if transport.MaxResponseHeaderBytes > 0 {
+			h2.MaxHeaderListSize = uint32(min(transport.MaxResponseHeaderBytes, int64(^uint32(0))))
+		}
+		return h2
+	}
+
+	if !c.Enabled {
+		return transport
+	}
+
+	h2, err := http2.ConfigureTransports(transport)
+	if err != nil {
+		// falls back to the transport's own HTTP/2 support
+		otel.Handle(err)
+		return transport
+	}
+	h2.ReadIdleTimeout = c.ReadIdleTimeout
+	h2.PingTimeout = c.PingTimeout
+
+	return transport
+}
+
+// reportH2CIgnored reports the configured transport options the h2c transport cannot apply, see WithH2C
+func (c TransportConfig) reportH2CIgnored() {
+	if !c.HTTP2.H2C {
+		return
+	}
+
+	var ignored []string
+	if c.Proxy.URL != "" || c.Proxy.FromEnvironment {
+		ignored = append(ignored, "proxy")
+	}
+	if c.MaxIdleConns != 0 || c.MaxIdleConnsPerHost != 0 || c.MaxConnsPerHost != 0 {
+		ignored = append(ignored, "connection limits")
+	}
+	if c.TLSConfig != nil || c.TLSHandshakeTimeout != 0 {
+		ignored = append(ignored, "TLS")
+	}
+	if c.ResponseHeaderTimeout != 0 {
+		ignored = append(ignored, "response header timeout")
+	}
+	if c.ExpectContinueTimeout != 0 {
+		ignored = append(ignored, "expect continue timeout")
+	}
+	if len(ignored) != 0 {
+		otel.Handle(fmt.Errorf("h2c transport ignores the %s options", strings.Join(ignored, ", ")))
+	}
+}
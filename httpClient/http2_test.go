@@ -0,0 +1,112 @@
+package httpClient
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdkTrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+func TestH2C(t *testing.T) {
+	server := httptest.NewServer(h2c.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, 2, r.ProtoMajor)
+		w.WriteHeader(http.StatusNoContent)
+	}), &http2.Server{}))
+	t.Cleanup(server.Close)
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdkTrace.NewTracerProvider(sdkTrace.WithSpanProcessor(recorder))
+
+	client := NewHTTPClient(
+		WithTraceEnabled(true),
+		WithTracerProvider(tp),
+		WithH2C(),
+		WithHTTP2HealthCheck(10*time.Second, 2*time.Second),
+	)
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	assert.Equal(t, 2, resp.ProtoMajor)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Contains(t, spans[0].Attributes(), protocolAttributeKey.String("2"))
+}
+
+// errorHandler collects the errors reported to otel.Handle
+type errorHandler struct {
+	errs []error
+}
+
+func (h *errorHandler) Handle(err error) {
+	h.errs = append(h.errs, err)
+}
+
+func TestH2CTransportOptions(t *testing.T) {
+	handler := &errorHandler{}
+	previous := otel.GetErrorHandler()
+	otel.SetErrorHandler(handler)
+	t.Cleanup(func() { otel.SetErrorHandler(previous) })
+
+	client := NewHTTPClient(
+		WithH2C(),
+		WithIdleConnTimeout(time.Minute),
+		WithProxy("http://proxy:3128"),
+		WithMaxConnsPerHost(10),
+		WithResponseHeaderTimeout(time.Second),
+	)
+
+	h2, ok := client.Client.Transport.(*http2.Transport)
+	require.True(t, ok)
+	assert.Equal(t, time.Minute, h2.IdleConnTimeout, "the supported options are carried over")
+
+	require.Len(t, handler.errs, 1)
+	assert.EqualError(t, handler.errs[0], "h2c transport ignores the proxy, connection limits, response header timeout options")
+}
+
+func TestHTTP2OverTLS(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	tlsConfig, err := NewTLSConfig(WithTLSCAPEM(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		opts          []HTTPClientOption
+		expectedMajor int
+	}{
+		{name: "http2", opts: []HTTPClientOption{WithTLSConfig(tlsConfig), WithHTTP2()}, expectedMajor: 2},
+		{name: "http2 with health check", opts: []HTTPClientOption{WithTLSConfig(tlsConfig), WithHTTP2HealthCheck(time.Second, time.Second)}, expectedMajor: 2},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+			require.NoError(t, err)
+			resp, err := NewHTTPClient(tc.opts...).Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+
+			assert.Equal(t, tc.expectedMajor, resp.ProtoMajor)
+		})
+	}
+}
@@ -2,15 +2,31 @@ package httpClient
 
 import (
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 	"net/http"
 	"runtime"
 )
 
 // HTTPClient wrapper over net/http client with tracing
 type HTTPClient struct {
-	Client       *http.Client
-	TraceEnabled bool
-	ServiceName  string
+	Client          *http.Client
+	TraceEnabled    bool
+	ServiceName     string
+	TracerProvider  trace.TracerProvider          // falls back to the global otel tracer provider
+	Propagator      propagation.TextMapPropagator // falls back to the global otel propagator
+	MeterProvider   metric.MeterProvider          // falls back to the global otel meter provider
+	Middlewares     []Middleware                  // run in order around every request, see Use
+	DefaultHeaders  http.Header                   // added to every request which does not have the header
+	TokenSource     TokenSource                   // authenticates the requests, see WithTokenSource
+	Signer          Signer                        // signs every attempt right before it is sent, see WithSigner
+	Credentials     *CredentialStore              // per host credentials applied before signing and on redirects, see WithCredentials
+	Debug           *DebugConfig                  // dumps every request as curl commands or wire format, see WithDebug
+	Cache           CacheStorage                  // caches the GET responses following RFC 9111, see WithCache
+	Coalescer       *Coalescer                    // collapses the concurrent identical GETs, see WithCoalescing
+	TransportConfig TransportConfig
 }
 
 // HTTPClientOption different options to configure the HTTPClient
@@ -23,26 +39,90 @@ func WithTraceEnabled(e bool) HTTPClientOption {
 	}
 }
 
-// NewHTTPClient creates a new HTTPClient with DefaultTransport
-// TODO: add options to configure transport
+// WithServiceName sets the name of the tracer used for the client spans
+func WithServiceName(name string) HTTPClientOption {
+	return func(client *HTTPClient) {
+		client.ServiceName = name
+	}
+}
+
+// WithTracerProvider uses tp instead of the global otel tracer provider
+func WithTracerProvider(tp trace.TracerProvider) HTTPClientOption {
+	return func(client *HTTPClient) {
+		client.TracerProvider = tp
+	}
+}
+
+// WithPropagator uses p instead of the global otel propagator to inject the trace context
+func WithPropagator(p propagation.TextMapPropagator) HTTPClientOption {
+	return func(client *HTTPClient) {
+		client.Propagator = p
+	}
+}
+
+// WithMeterProvider uses mp instead of the global otel meter provider
+func WithMeterProvider(mp metric.MeterProvider) HTTPClientOption {
+	return func(client *HTTPClient) {
+		client.MeterProvider = mp
+	}
+}
+
+// NewHTTPClient creates a new HTTPClient with its own clone of DefaultTransport configured by the transport options
 func NewHTTPClient(opt ...HTTPClientOption) *HTTPClient {
 	httpClient := HTTPClient{Client: &http.Client{}}
 	for _, option := range opt {
 		option(&httpClient)
 	}
 
+	transport := httpClient.newTransport()
+	if httpClient.Credentials != nil {
+		transport = credentialsTransport{next: transport, store: httpClient.Credentials}
+	}
+	if httpClient.Cache != nil {
+		transport = newCacheTransport(transport, httpClient.Cache, &httpClient)
+	}
 	if httpClient.TraceEnabled {
-		httpClient.Client.Transport = otelhttp.NewTransport(http.DefaultTransport)
+		httpClient.Client.Transport = otelhttp.NewTransport(spanAttributesRecorder{next: transport}, httpClient.otelOptions()...)
 	} else {
-		httpClient.Client.Transport = http.DefaultTransport.(*http.Transport)
+		httpClient.Client.Transport = transport
 	}
 
 	return &httpClient
 }
 
-// Do makes an HTTP request with the native `http.Do` interface
+// otelOptions returns the otelhttp options for the configured providers, unset ones fall back to the globals
+func (c *HTTPClient) otelOptions() []otelhttp.Option {
+	opts := []otelhttp.Option{otelhttp.WithMetricAttributesFn(routeMetricAttributes)}
+	if c.TracerProvider != nil {
+		opts = append(opts, otelhttp.WithTracerProvider(c.TracerProvider))
+	}
+	if c.Propagator != nil {
+		opts = append(opts, otelhttp.WithPropagators(c.Propagator))
+	}
+	if c.MeterProvider != nil {
+		opts = append(opts, otelhttp.WithMeterProvider(c.MeterProvider))
+	}
+	return opts
+}
+
+// Tracer returns the tracer for ServiceName from the configured tracer provider, else from the global one
+func (c *HTTPClient) Tracer() trace.Tracer {
+	if c.TracerProvider != nil {
+		return c.TracerProvider.Tracer(c.ServiceName)
+	}
+	return otel.Tracer(c.ServiceName)
+}
+
+// Do makes an HTTP request with the native `http.Do` interface through the middleware chain, after adding the default headers.
+// With a Coalescer the identical GETs in flight share one pass through the chain
 func (c *HTTPClient) Do(request *http.Request) (*http.Response, error) {
-	resp, err := c.Client.Do(request)
+	var resp *http.Response
+	var err error
+	if c.Coalescer != nil {
+		resp, err = c.Coalescer.do(c.withDefaultHeaders(request), c.roundTrip())
+	} else {
+		resp, err = c.roundTrip()(c.withDefaultHeaders(request))
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,368 @@
+package httpClient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// redacted replaces the value of the redacted headers, query params and JSON fields
+const redacted = "REDACTED"
+
+// secrets always redacted from the logs and debug dumps
+var (
+	defaultRedactedHeaders     = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+	defaultRedactedQueryParams = []string{"access_token", "api_key", "key"}
+	defaultRedactedJSONFields  = []string{"password", "client_secret", "access_token", "refresh_token"}
+)
+
+// LoggingConfig different configurations of the logging middleware
+type LoggingConfig struct {
+	Logger            *slog.Logger // slog.Default() when nil
+	Level             slog.Level   // level of the successful requests, 4xx are logged as warn, 5xx and errors as error
+	LogHeaders        bool
+	LogBodies         bool
+	MaxBodySize       int // bytes of each body logged at most
+	RedactHeaders     []string
+	RedactQueryParams []string
+	RedactJSONFields  []string // redacted at any depth of the JSON bodies
+}
+
+// LoggingOption different options to configure the logging middleware
+type LoggingOption func(config *LoggingConfig)
+
+// WithLogger logs with l instead of slog.Default()
+func WithLogger(l *slog.Logger) LoggingOption {
+	return func(config *LoggingConfig) {
+		config.Logger = l
+	}
+}
+
+// WithLogLevel sets the level of the successful requests, info by default
+func WithLogLevel(level slog.Level) LoggingOption {
+	return func(config *LoggingConfig) {
+		config.Level = level
+	}
+}
+
+// WithHeaderLogging logs the request and response headers
+func WithHeaderLogging() LoggingOption {
+	return func(config *LoggingConfig) {
+		config.LogHeaders = true
+	}
+}
+
+// WithBodyLogging logs at most maxSize bytes of the request and response bodies, a JSON body exceeding it is not logged
+// as its fields cannot be redacted
+func WithBodyLogging(maxSize int) LoggingOption {
+	return func(config *LoggingConfig) {
+		config.LogBodies = true
+		config.MaxBodySize = maxSize
+	}
+}
+
+// WithRedactedHeaders redacts the headers in addition to Authorization, Proxy-Authorization, Cookie, Set-Cookie and X-Api-Key
+func WithRedactedHeaders(names ...string) LoggingOption {
+	return func(config *LoggingConfig) {
+		config.RedactHeaders = append(config.RedactHeaders, names...)
+	}
+}
+
+// WithRedactedQueryParams redacts the query params of the logged URLs and form bodies in addition to access_token, api_key and key
+func WithRedactedQueryParams(names ...string) LoggingOption {
+	return func(config *LoggingConfig) {
+		config.RedactQueryParams = append(config.RedactQueryParams, names...)
+	}
+}
+
+// WithRedactedJSONFields redacts the fields of the logged JSON and form bodies in addition to password, client_secret,
+// access_token and refresh_token
+func WithRedactedJSONFields(names ...string) LoggingOption {
+	return func(config *LoggingConfig) {
+		config.RedactJSONFields = append(config.RedactJSONFields, names...)
+	}
+}
+
+// WithLogging registers the logging middleware, see LoggingMiddleware
+func WithLogging(opts ...LoggingOption) HTTPClientOption {
+	return func(client *HTTPClient) {
+		client.Use(LoggingMiddleware(opts...))
+	}
+}
+
+// attemptKey context key of the attempt number of a request
+type attemptKey struct{}
+
+// ContextWithAttempt records the attempt number of the request, the token authentication sets it on its retry on 401
+// and retrying middlewares for the middlewares after them
+func ContextWithAttempt(ctx context.Context, attempt int) context.Context {
+	return context.WithValue(ctx, attemptKey{}, attempt)
+}
+
+// AttemptFromContext returns the attempt number of the request, 1 when not set
+func AttemptFromContext(ctx context.Context) int {
+	if attempt, ok := ctx.Value(attemptKey{}).(int); ok {
+		return attempt
+	}
+	return 1
+}
+
+// LoggingMiddleware logs every request with log/slog: method, URL, status, latency, attempt, trace and span IDs for correlation
+// and optionally the headers and bodies. Secrets are redacted from the headers, the query params and the JSON bodies
+func LoggingMiddleware(opts ...LoggingOption) Middleware {
+	config := &LoggingConfig{
+		Level:             slog.LevelInfo,
+		RedactHeaders:     append([]string(nil), defaultRedactedHeaders...),
+		RedactQueryParams: append([]string(nil), defaultRedactedQueryParams...),
+		RedactJSONFields:  append([]string(nil), defaultRedactedJSONFields...),
+	}
+	for _, opt := range opts {
+		opt(config)
+	}
+	r := newRedactor(config.RedactHeaders, config.RedactQueryParams, config.RedactJSONFields)
+
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(request *http.Request) (*http.Response, error) {
+			logger := config.Logger
+			if logger == nil {
+				logger = slog.Default()
+			}
+			ctx := request.Context()
+
+			attrs := []slog.Attr{
+				slog.String("method", request.Method),
+				slog.String("url", r.url(request.URL)),
+			}
+			if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+				attrs = append(attrs,
+					slog.String("trace_id", spanContext.TraceID().String()),
+					slog.String("span_id", spanContext.SpanID().String()),
+				)
+			}
+			if config.LogHeaders {
+				attrs = append(attrs, slog.Any("request_headers", r.headers(request.Header)))
+			}
+			if config.LogBodies {
+				if body, ok := peekRequestBody(request, config.MaxBodySize); ok {
+					attrs = append(attrs, slog.String("request_body", r.body(body, request.Header.Get("Content-Type"), config.MaxBodySize)))
+				}
+			}
+
+			start := time.Now()
+			resp, err := next(request)
+			attrs = append(attrs, slog.Duration("latency", time.Since(start)), slog.Int("attempt", responseAttempt(ctx, resp)))
+
+			level := config.Level
+			switch {
+			case err != nil:
+				level = slog.LevelError
+				attrs = append(attrs, slog.String("error", err.Error()))
+			default:
+				attrs = append(attrs, slog.Int("status", resp.StatusCode))
+				if resp.StatusCode >= 500 {
+					level = slog.LevelError
+				} else if resp.StatusCode >= 400 {
+					level = slog.LevelWarn
+				}
+				if config.LogHeaders {
+					attrs = append(attrs, slog.Any("response_headers", r.headers(resp.Header)))
+				}
+				if config.LogBodies {
+					body := peekResponseBody(resp, config.MaxBodySize)
+					attrs = append(attrs, slog.String("response_body", r.body(body, resp.Header.Get("Content-Type"), config.MaxBodySize)))
+				}
+			}
+
+			logger.LogAttrs(ctx, level, "http request", attrs...)
+			return resp, err
+		}
+	}
+}
+
+// responseAttempt returns the attempt number of the request which got the response, e.g. 2 once the token authentication
+// retried on 401, the one of ctx without response
+func responseAttempt(ctx context.Context, resp *http.Response) int {
+	if resp != nil && resp.Request != nil {
+		return AttemptFromContext(resp.Request.Context())
+	}
+	return AttemptFromContext(ctx)
+}
+
+// peekRequestBody returns at most maxSize+1 bytes of the request body without consuming it, ok is false if it cannot be replayed
+func peekRequestBody(request *http.Request, maxSize int) ([]byte, bool) {
+	if request.Body == nil || request.Body == http.NoBody {
+		return nil, true
+	}
+	if request.GetBody == nil {
+		return nil, false
+	}
+	body, err := request.GetBody()
+	if err != nil {
+		return nil, false
+	}
+	defer body.Close()
+
+	b, _ := io.ReadAll(io.LimitReader(body, int64(maxSize)+1))
+	return b, true
+}
+
+// peekResponseBody returns at most maxSize+1 bytes of the response body, the body still reads from its start
+func peekResponseBody(resp *http.Response, maxSize int) []byte {
+	if resp.Body == nil || resp.Body == http.NoBody {
+		return nil
+	}
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, int64(maxSize)+1))
+	resp.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(b), resp.Body), Closer: resp.Body}
+	return b
+}
+
+// readCloser reads from Reader and closes Closer
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// redactor redacts the secrets of the logged values
+type redactor struct {
+	headerNames map[string]struct{}
+	query       map[string]struct{}
+	jsonFields  map[string]struct{}
+}
+
+func newRedactor(headers, queryParams, jsonFields []string) *redactor {
+	r := &redactor{
+		headerNames: map[string]struct{}{},
+		query:       map[string]struct{}{},
+		jsonFields:  map[string]struct{}{},
+	}
+	for _, name := range headers {
+		r.headerNames[http.CanonicalHeaderKey(name)] = struct{}{}
+	}
+	for _, name := range queryParams {
+		r.query[name] = struct{}{}
+	}
+	for _, name := range jsonFields {
+		r.jsonFields[strings.ToLower(name)] = struct{}{}
+	}
+	return r
+}
+
+// url returns the URL with the password of the user info and the redacted query params replaced
+func (r *redactor) url(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	query := u.Query()
+	changed := false
+	for key, values := range query {
+		if _, ok := r.query[key]; ok {
+			for i := range values {
+				values[i] = redacted
+			}
+			changed = true
+		}
+	}
+
+	c := *u
+	if changed {
+		c.RawQuery = query.Encode()
+	}
+	return c.Redacted()
+}
+
+// headers returns a copy of the headers with the redacted ones replaced
+func (r *redactor) headers(header http.Header) http.Header {
+	c := header.Clone()
+	for key, values := range c {
+		if _, ok := r.headerNames[http.CanonicalHeaderKey(key)]; ok {
+			for i := range values {
+				values[i] = redacted
+			}
+		}
+	}
+	return c
+}
+
+// body returns the loggable body, JSON bodies have their redacted fields replaced and are omitted if truncated
+func (r *redactor) body(b []byte, contentType string, maxSize int) string {
+	truncated := len(b) > maxSize
+	if truncated {
+		b = b[:maxSize]
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "application/x-www-form-urlencoded" && !truncated {
+		return r.form(b)
+	}
+	if mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json") {
+		if truncated {
+			return string(b) + "...(truncated)"
+		}
+		return string(b)
+	}
+	if len(b) == 0 {
+		return ""
+	}
+
+	if truncated {
+		return fmt.Sprintf("(JSON body of more than %d bytes omitted)", maxSize)
+	}
+	var v any
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	if err := decoder.Decode(&v); err != nil {
+		return "(invalid JSON body omitted)"
+	}
+	redactedBody, err := json.Marshal(r.json(v))
+	if err != nil {
+		return "(JSON body omitted)"
+	}
+	return string(redactedBody)
+}
+
+// form returns the form body with the fields named as the redacted query params or JSON fields replaced
+func (r *redactor) form(b []byte) string {
+	values, err := url.ParseQuery(string(b))
+	if err != nil {
+		return "(invalid form body omitted)"
+	}
+	for key, vs := range values {
+		_, isQueryParam := r.query[key]
+		_, isJSONField := r.jsonFields[strings.ToLower(key)]
+		if isQueryParam || isJSONField {
+			for i := range vs {
+				vs[i] = redacted
+			}
+		}
+	}
+	return values.Encode()
+}
+
+// json replaces the redacted fields at any depth of v
+func (r *redactor) json(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for key, value := range t {
+			if _, ok := r.jsonFields[strings.ToLower(key)]; ok {
+				t[key] = redacted
+				continue
+			}
+			t[key] = r.json(value)
+		}
+	case []any:
+		for i, value := range t {
+			t[i] = r.json(value)
+		}
+	}
+	return v
+}
@@ -0,0 +1,131 @@
+package httpClient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestLoggingMiddleware(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=secret")
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		_, _ = w.Write([]byte(`{"id":12345678901234567890,"token":{"access_token":"secret"},"items":[{"password":"secret"}]}`))
+	}))
+	t.Cleanup(server.Close)
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, nil))
+	client := NewHTTPClient(WithLogging(
+		WithLogger(logger),
+		WithHeaderLogging(),
+		WithBodyLogging(1024),
+		WithRedactedHeaders("X-Partner-Secret"),
+		WithRedactedQueryParams("signature"),
+		WithRedactedJSONFields("ssn"),
+	))
+
+	spanContext := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1, 2, 3},
+		SpanID:     trace.SpanID{4, 5, 6},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := ContextWithAttempt(trace.ContextWithSpanContext(context.Background(), spanContext), 2)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, server.URL+"/users?signature=abc&page=1", strings.NewReader(`{"name":"john","ssn":"123"}`))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("X-Partner-Secret", "secret")
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Contains(t, string(body), `"access_token":"secret"`, "the response body is still readable and not redacted")
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(logs.Bytes(), &entry))
+	assert.NotContains(t, logs.String(), "secret")
+	assert.NotContains(t, logs.String(), "abc")
+
+	assert.Equal(t, "INFO", entry["level"])
+	assert.Equal(t, "http request", entry["msg"])
+	assert.Equal(t, http.MethodPost, entry["method"])
+	assert.Equal(t, server.URL+"/users?page=1&signature=REDACTED", entry["url"])
+	assert.Equal(t, float64(200), entry["status"])
+	assert.Equal(t, float64(2), entry["attempt"])
+	assert.Equal(t, spanContext.TraceID().String(), entry["trace_id"])
+	assert.Equal(t, spanContext.SpanID().String(), entry["span_id"])
+	assert.Contains(t, entry, "latency")
+	assert.Equal(t, `{"name":"john","ssn":"REDACTED"}`, entry["request_body"])
+	assert.Equal(t, `{"id":12345678901234567890,"items":[{"password":"REDACTED"}],"token":{"access_token":"REDACTED"}}`, entry["response_body"])
+	assert.Equal(t, []any{"REDACTED"}, entry["request_headers"].(map[string]any)["Authorization"])
+	assert.Equal(t, []any{"REDACTED"}, entry["response_headers"].(map[string]any)["Set-Cookie"])
+
+	logs.Reset()
+	req, err = http.NewRequest(http.MethodGet, server.URL+"/missing", nil)
+	require.NoError(t, err)
+
+	resp, err = client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	require.NoError(t, json.Unmarshal(logs.Bytes(), &entry))
+	assert.Equal(t, "WARN", entry["level"])
+	assert.Equal(t, float64(1), entry["attempt"])
+}
+
+func TestLoggingAttempt(t *testing.T) {
+	tokenServer, _ := newTokenServer(t, 3600, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token-2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+
+	var logs bytes.Buffer
+	client := NewHTTPClient(
+		WithLogging(WithLogger(slog.New(slog.NewJSONHandler(&logs, nil)))),
+		WithTokenSource(ClientCredentials(tokenServer.URL, "client", "secret", WithScopes("read", "write"))),
+	)
+
+	for _, expected := range []float64{2, 1} {
+		logs.Reset()
+		req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+		require.NoError(t, err)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal(logs.Bytes(), &entry))
+		assert.Equal(t, float64(http.StatusNoContent), entry["status"])
+		assert.Equal(t, expected, entry["attempt"], "the attempt of the response is logged, 2 once retried on 401")
+	}
+}
+
+func TestLoggingRedactsTruncatedJSON(t *testing.T) {
+	r := newRedactor(nil, nil, []string{"password"})
+
+	assert.Equal(t, "(JSON body of more than 10 bytes omitted)", r.body([]byte(`{"password":"secret"}`), "application/json", 10))
+	assert.Equal(t, "(invalid JSON body omitted)", r.body([]byte(`{"password":`), "application/problem+json", 100))
+	assert.Equal(t, "plain text...(truncated)", r.body([]byte("plain text body"), "text/plain", 10))
+}
@@ -0,0 +1,44 @@
+package httpClient
+
+import "net/http"
+
+// RoundTripFunc sends a request and returns its response, the innermost one being the underlying http.Client
+type RoundTripFunc func(request *http.Request) (*http.Response, error)
+
+// Middleware wraps a RoundTripFunc to add auth, logging, header injection, fault injection etc. once per client.
+//
+// Middlewares run once per call to HTTPClient.Do inside the rustic span and the circuit breaker, hence a failure returned by a
+// middleware counts as a breaker failure, and outside the transport, hence the otelhttp client span is a child of
+// whatever a middleware adds to the request context. They sit outside the token authentication, hence do not see its retry on 401
+type Middleware func(next RoundTripFunc) RoundTripFunc
+
+// WithMiddlewares registers the middlewares, see HTTPClient.Use
+func WithMiddlewares(middlewares ...Middleware) HTTPClientOption {
+	return func(client *HTTPClient) {
+		client.Use(middlewares...)
+	}
+}
+
+// Use appends the middlewares to the chain, the first registered middleware is the outermost one.
+// It is not safe to call Use concurrently with Do, register the middlewares before making requests
+func (c *HTTPClient) Use(middlewares ...Middleware) {
+	c.Middlewares = append(c.Middlewares, middlewares...)
+}
+
+// roundTrip builds the middleware chain around the authentication, the per host credentials, the signing, the debug dumps of the requests and the underlying http.Client
+func (c *HTTPClient) roundTrip() RoundTripFunc {
+	next := c.debug(c.Client.Do)
+	if c.Signer != nil {
+		next = c.sign(next)
+	}
+	if c.Credentials != nil {
+		next = c.applyCredentials(next)
+	}
+	if c.TokenSource != nil {
+		next = c.authenticate(next)
+	}
+	for i := len(c.Middlewares) - 1; i >= 0; i-- {
+		next = c.Middlewares[i](next)
+	}
+	return next
+}
@@ -0,0 +1,167 @@
+package httpClient
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkTrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestProxy(t *testing.T) {
+	// proxy stand-in answering the proxied requests itself
+	proxied := make(chan *http.Request, 1)
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied <- r
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(proxy.Close)
+
+	proxyURL, err := url.Parse(proxy.URL)
+	require.NoError(t, err)
+	proxyURL.User = url.UserPassword("user", "secret")
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdkTrace.NewTracerProvider(sdkTrace.WithSpanProcessor(recorder))
+
+	client := NewHTTPClient(
+		WithTraceEnabled(true),
+		WithTracerProvider(tp),
+		WithProxy(proxyURL.String()),
+	)
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "http://upstream.test/users", nil)
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	r := <-proxied
+	assert.Equal(t, "http://upstream.test/users", r.RequestURI)
+	assert.Equal(t, "Basic "+base64.StdEncoding.EncodeToString([]byte("user:secret")), r.Header.Get("Proxy-Authorization"))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Contains(t, spans[0].Attributes(), proxyAttributeKey.String(proxyURL.Redacted()))
+	assert.NotContains(t, spans[0].Attributes(), attribute.String("http.proxy", proxyURL.String()))
+}
+
+func TestProxySelection(t *testing.T) {
+	testCases := []struct {
+		name          string
+		opts          []HTTPClientOption
+		env           map[string]string
+		target        string
+		expectedProxy string
+	}{
+		{
+			name:          "fixed proxy",
+			opts:          []HTTPClientOption{WithProxy("http://proxy:3128")},
+			target:        "https://api.partner.com/v1",
+			expectedProxy: "http://proxy:3128",
+		},
+		{
+			name:   "bypassed internal host",
+			opts:   []HTTPClientOption{WithProxy("http://proxy:3128"), WithNoProxy(".internal", "10.0.0.0/8")},
+			target: "http://users.svc.internal/v1",
+		},
+		{
+			name:   "bypassed internal CIDR",
+			opts:   []HTTPClientOption{WithProxy("http://proxy:3128"), WithNoProxy(".internal", "10.0.0.0/8")},
+			target: "http://10.1.2.3:8080/v1",
+		},
+		{
+			name:          "socks5 proxy",
+			opts:          []HTTPClientOption{WithProxy("socks5://proxy:1080")},
+			target:        "https://api.partner.com/v1",
+			expectedProxy: "socks5://proxy:1080",
+		},
+		{
+			name:          "proxy from environment with extra bypass",
+			opts:          []HTTPClientOption{WithProxyFromEnvironment(), WithNoProxy("partner.com")},
+			env:           map[string]string{"HTTPS_PROXY": "http://env-proxy:3128", "NO_PROXY": "other.com"},
+			target:        "https://api.other.com/v1",
+			expectedProxy: "",
+		},
+		{
+			name:          "proxy from environment",
+			opts:          []HTTPClientOption{WithProxyFromEnvironment(), WithNoProxy("partner.com")},
+			env:           map[string]string{"HTTPS_PROXY": "http://env-proxy:3128", "NO_PROXY": "other.com"},
+			target:        "https://api.example.com/v1",
+			expectedProxy: "http://env-proxy:3128",
+		},
+		{
+			name:          "bypass list keeps the proxy from environment",
+			opts:          []HTTPClientOption{WithNoProxy("partner.com")},
+			env:           map[string]string{"HTTPS_PROXY": "http://env-proxy:3128"},
+			target:        "https://api.example.com/v1",
+			expectedProxy: "http://env-proxy:3128",
+		},
+		{
+			name:          "explicit proxy wins over the environment",
+			opts:          []HTTPClientOption{WithProxy("http://proxy:3128"), WithProxyFromEnvironment()},
+			env:           map[string]string{"HTTPS_PROXY": "http://env-proxy:3128", "NO_PROXY": "other.com"},
+			target:        "https://api.example.com/v1",
+			expectedProxy: "http://proxy:3128",
+		},
+		{
+			name:   "explicit proxy with the bypass list of the environment",
+			opts:   []HTTPClientOption{WithProxy("http://proxy:3128"), WithProxyFromEnvironment()},
+			env:    map[string]string{"HTTPS_PROXY": "http://env-proxy:3128", "NO_PROXY": "other.com"},
+			target: "https://api.other.com/v1",
+		},
+		{
+			name:          "explicit proxy ignores the bypass list of the environment",
+			opts:          []HTTPClientOption{WithProxy("http://proxy:3128")},
+			env:           map[string]string{"NO_PROXY": "other.com"},
+			target:        "https://api.other.com/v1",
+			expectedProxy: "http://proxy:3128",
+		},
+		{
+			name:   "without proxy",
+			opts:   []HTTPClientOption{WithoutProxy()},
+			env:    map[string]string{"HTTPS_PROXY": "http://env-proxy:3128"},
+			target: "https://api.example.com/v1",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for key, value := range tc.env {
+				t.Setenv(key, value)
+			}
+
+			transport := NewHTTPClient(tc.opts...).Client.Transport.(*http.Transport)
+			if transport.Proxy == nil {
+				assert.Empty(t, tc.expectedProxy)
+				return
+			}
+
+			req, err := http.NewRequest(http.MethodGet, tc.target, nil)
+			require.NoError(t, err)
+			proxyURL, err := transport.Proxy(req)
+			require.NoError(t, err)
+
+			if tc.expectedProxy == "" {
+				assert.Nil(t, proxyURL)
+				return
+			}
+			require.NotNil(t, proxyURL)
+			assert.Equal(t, tc.expectedProxy, proxyURL.String())
+		})
+	}
+}
+
+func TestProxyConnectHeaders(t *testing.T) {
+	headers := http.Header{"Proxy-Authorization": []string{"Bearer token"}}
+	transport := NewHTTPClient(WithProxy("http://proxy:3128"), WithProxyConnectHeaders(headers)).Client.Transport.(*http.Transport)
+	assert.Equal(t, headers, transport.ProxyConnectHeader)
+}
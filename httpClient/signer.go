@@ -0,0 +1,148 @@
+package httpClient
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Signer signs the request once its body is encoded, body is the exact payload sent.
+// It is called again for every attempt, including the retry on 401, hence timestamps and signatures are always fresh
+type Signer interface {
+	Sign(request *http.Request, body []byte) error
+}
+
+// SignerFunc adapts a function to a Signer
+type SignerFunc func(request *http.Request, body []byte) error
+
+func (f SignerFunc) Sign(request *http.Request, body []byte) error {
+	return f(request, body)
+}
+
+// WithSigner signs every request of the client with s right before it is sent
+func WithSigner(s Signer) HTTPClientOption {
+	return func(client *HTTPClient) {
+		client.Signer = s
+	}
+}
+
+// sign signs a copy of every request with the Signer
+func (c *HTTPClient) sign(next RoundTripFunc) RoundTripFunc {
+	return func(request *http.Request) (*http.Response, error) {
+		signed := request.Clone(request.Context())
+		if signed.Header == nil {
+			signed.Header = http.Header{}
+		}
+
+		body, err := readBody(signed)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.Signer.Sign(signed, body); err != nil {
+			return nil, fmt.Errorf("failed to sign request: %w", err)
+		}
+
+		return next(signed)
+	}
+}
+
+// readBody reads the whole body of the request and replaces it with a replayable one
+func readBody(request *http.Request) ([]byte, error) {
+	if request.Body == nil || request.Body == http.NoBody {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(request.Body)
+	request.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+
+	request.Body = io.NopCloser(bytes.NewReader(body))
+	request.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	return body, nil
+}
+
+// sha256Hex returns the hex encoded SHA-256 of b
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// HMACSigner signs the requests with HMAC-SHA256 over
+//
+//	METHOD + "\n" + escaped path and raw query + "\n" + hex SHA-256 of the body + "\n" + unix timestamp
+//
+// and sends the hex encoded signature, the timestamp and the key id, if any, as headers
+type HMACSigner struct {
+	KeyID           string
+	Secret          []byte
+	SignatureHeader string // X-Signature by default
+	TimestampHeader string // X-Timestamp by default
+	KeyIDHeader     string // X-Key-Id by default
+
+	now func() time.Time
+}
+
+// HMACOption different options to configure the HMACSigner
+type HMACOption func(signer *HMACSigner)
+
+// WithHMACHeaders overrides the names of the signature, timestamp and key id headers, empty names keep the defaults
+func WithHMACHeaders(signature, timestamp, keyID string) HMACOption {
+	return func(signer *HMACSigner) {
+		if signature != "" {
+			signer.SignatureHeader = signature
+		}
+		if timestamp != "" {
+			signer.TimestampHeader = timestamp
+		}
+		if keyID != "" {
+			signer.KeyIDHeader = keyID
+		}
+	}
+}
+
+// NewHMACSigner creates an HMACSigner with the shared secret, keyID identifies the secret to the partner and may be empty
+func NewHMACSigner(keyID string, secret []byte, opts ...HMACOption) *HMACSigner {
+	signer := &HMACSigner{
+		KeyID:           keyID,
+		Secret:          secret,
+		SignatureHeader: "X-Signature",
+		TimestampHeader: "X-Timestamp",
+		KeyIDHeader:     "X-Key-Id",
+		now:             time.Now,
+	}
+	for _, opt := range opts {
+		opt(signer)
+	}
+	return signer
+}
+
+// Sign sets the signature, timestamp and key id headers of the request
+func (s *HMACSigner) Sign(request *http.Request, body []byte) error {
+	timestamp := strconv.FormatInt(s.now().Unix(), 10)
+
+	signature := hex.EncodeToString(s.signature(request.Method, request.URL.RequestURI(), body, timestamp))
+
+	request.Header.Set(s.SignatureHeader, signature)
+	request.Header.Set(s.TimestampHeader, timestamp)
+	if s.KeyID != "" {
+		request.Header.Set(s.KeyIDHeader, s.KeyID)
+	}
+	return nil
+}
+
+// signature returns the HMAC-SHA256 of the string to sign
+func (s *HMACSigner) signature(method, requestURI string, body []byte, timestamp string) []byte {
+	mac := hmac.New(sha256.New, s.Secret)
+	mac.Write([]byte(method + "\n" + requestURI + "\n" + sha256Hex(body) + "\n" + timestamp))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,99 @@
+package httpClient
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHMACSigner(t *testing.T) {
+	secret := []byte("shared-secret")
+
+	var calls atomic.Int32
+	var timestamps []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, `{"amount":10}`, string(body))
+		assert.Equal(t, "partner-1", r.Header.Get("X-Key-Id"))
+
+		bodyHash := sha256.Sum256(body)
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(r.Method + "\n" + r.URL.RequestURI() + "\n" + hex.EncodeToString(bodyHash[:]) + "\n" + r.Header.Get("X-Timestamp")))
+		assert.Equal(t, hex.EncodeToString(mac.Sum(nil)), r.Header.Get("X-Signature"))
+		timestamps = append(timestamps, r.Header.Get("X-Timestamp"))
+
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+
+	var elapsed atomic.Int64
+	signer := NewHMACSigner("partner-1", secret)
+	signer.now = func() time.Time { return time.Unix(1700000000+elapsed.Add(1), 0) }
+
+	retryOnce := func(next RoundTripFunc) RoundTripFunc {
+		return func(request *http.Request) (*http.Response, error) {
+			resp, err := next(request)
+			if err != nil || resp.StatusCode != http.StatusServiceUnavailable {
+				return resp, err
+			}
+			resp.Body.Close()
+			retry := request.Clone(request.Context())
+			if retry.Body, err = request.GetBody(); err != nil {
+				return nil, err
+			}
+			return next(retry)
+		}
+	}
+
+	client := NewHTTPClient(WithSigner(signer), WithMiddlewares(retryOnce))
+
+	req, err := http.NewRequest(http.MethodPost, server.URL+"/payments?currency=EUR", strings.NewReader(`{"amount":10}`))
+	require.NoError(t, err)
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	require.Len(t, timestamps, 2)
+	assert.NotEqual(t, timestamps[0], timestamps[1], "every attempt is signed again")
+	assert.Empty(t, req.Header.Get("X-Signature"), "the caller's request is not mutated")
+}
+
+func TestSignerRunsAfterAuthentication(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer static|signed", r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewHTTPClient(
+		WithTokenSource(StaticTokenSource("static")),
+		WithSigner(SignerFunc(func(request *http.Request, body []byte) error {
+			request.Header.Set("Authorization", request.Header.Get("Authorization")+"|signed")
+			return nil
+		})),
+	)
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+}
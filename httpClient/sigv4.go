@@ -0,0 +1,179 @@
+package httpClient
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// sigV4 constants of the AWS Signature Version 4
+const (
+	sigV4Algorithm  = "AWS4-HMAC-SHA256"
+	sigV4TimeFormat = "20060102T150405Z"
+	sigV4DateFormat = "20060102"
+)
+
+// SigV4Signer signs the requests with AWS Signature Version 4, e.g. for S3 compatible stores
+type SigV4Signer struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	Region          string
+	Service         string
+
+	now func() time.Time
+}
+
+// SigV4Option different options to configure the SigV4Signer
+type SigV4Option func(signer *SigV4Signer)
+
+// WithSigV4SessionToken sends the session token of temporary credentials as X-Amz-Security-Token
+func WithSigV4SessionToken(token string) SigV4Option {
+	return func(signer *SigV4Signer) {
+		signer.SessionToken = token
+	}
+}
+
+// NewSigV4Signer creates a SigV4Signer for the region and service, e.g. us-east-1 and s3
+func NewSigV4Signer(accessKeyID, secretAccessKey, region, service string, opts ...SigV4Option) *SigV4Signer {
+	signer := &SigV4Signer{
+		AccessKeyID:     accessKeyID,
+		SecretAccessKey: secretAccessKey,
+		Region:          region,
+		Service:         service,
+		now:             time.Now,
+	}
+	for _, opt := range opts {
+		opt(signer)
+	}
+	return signer
+}
+
+// Sign sets the X-Amz-Date, X-Amz-Content-Sha256 for s3, X-Amz-Security-Token if any and Authorization headers of the request
+func (s *SigV4Signer) Sign(request *http.Request, body []byte) error {
+	now := s.now().UTC()
+	amzDate := now.Format(sigV4TimeFormat)
+	scope := strings.Join([]string{now.Format(sigV4DateFormat), s.Region, s.Service, "aws4_request"}, "/")
+	payloadHash := sha256Hex(body)
+
+	request.Header.Set("X-Amz-Date", amzDate)
+	if s.Service == "s3" {
+		request.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	}
+	if s.SessionToken != "" {
+		request.Header.Set("X-Amz-Security-Token", s.SessionToken)
+	}
+
+	host := request.Host
+	if host == "" {
+		host = request.URL.Host
+	}
+	if host == "" {
+		return fmt.Errorf("request has no host")
+	}
+
+	signedHeaders, canonicalHeaders := sigV4Headers(request.Header, host)
+	canonicalRequest := strings.Join([]string{
+		request.Method,
+		s.canonicalURI(request.URL),
+		sigV4Query(request.URL.Query()),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	stringToSign := strings.Join([]string{sigV4Algorithm, amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.SecretAccessKey), now.Format(sigV4DateFormat))
+	for _, part := range []string{s.Region, s.Service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	request.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sigV4Algorithm, s.AccessKeyID, scope, signedHeaders, signature))
+	return nil
+}
+
+// canonicalURI URI encodes every segment of the path, twice for every service but s3
+func (s *SigV4Signer) canonicalURI(u *url.URL) string {
+	path := u.Path
+	if path == "" {
+		return "/"
+	}
+
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = sigV4Encode(segment)
+		if s.Service != "s3" {
+			segments[i] = sigV4Encode(segments[i])
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// sigV4Query returns the query params sorted by key and value, each one URI encoded
+func sigV4Query(query url.Values) string {
+	params := make([]string, 0, len(query))
+	for key, values := range query {
+		for _, value := range values {
+			params = append(params, sigV4Encode(key)+"="+sigV4Encode(value))
+		}
+	}
+	sort.Strings(params)
+	return strings.Join(params, "&")
+}
+
+// sigV4Headers returns the signed header names and the canonical headers: host, content-type and every x-amz-* header
+func sigV4Headers(header http.Header, host string) (string, string) {
+	headers := map[string]string{"host": host}
+	for key, values := range header {
+		name := strings.ToLower(key)
+		if name != "content-type" && !strings.HasPrefix(name, "x-amz-") {
+			continue
+		}
+		trimmed := make([]string, len(values))
+		for i, value := range values {
+			trimmed[i] = strings.Join(strings.Fields(value), " ")
+		}
+		headers[name] = strings.Join(trimmed, ",")
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonical strings.Builder
+	for _, name := range names {
+		canonical.WriteString(name + ":" + headers[name] + "\n")
+	}
+	return strings.Join(names, ";"), canonical.String()
+}
+
+// sigV4Encode URI encodes every byte but the unreserved characters, RFC 3986
+func sigV4Encode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
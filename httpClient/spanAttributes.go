@@ -0,0 +1,60 @@
+package httpClient
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// protocolAttributeKey span attribute holding the negotiated protocol version, e.g. 1.1 or 2
+	protocolAttributeKey = attribute.Key("network.protocol.version")
+	// RouteAttributeKey span and metric attribute holding the route template of the request, e.g. /users/{id}
+	RouteAttributeKey = attribute.Key("http.route")
+)
+
+type routeContextKey struct{}
+
+// ContextWithRoute stores the route template of the request, recorded as http.route on the client span and metrics
+func ContextWithRoute(ctx context.Context, route string) context.Context {
+	return context.WithValue(ctx, routeContextKey{}, route)
+}
+
+// RouteFromContext returns the route template stored by ContextWithRoute
+func RouteFromContext(ctx context.Context) (string, bool) {
+	route, ok := ctx.Value(routeContextKey{}).(string)
+	return route, ok
+}
+
+// routeMetricAttributes adds the route template to the client metrics
+func routeMetricAttributes(r *http.Request) []attribute.KeyValue {
+	if route, ok := RouteFromContext(r.Context()); ok {
+		return []attribute.KeyValue{RouteAttributeKey.String(route)}
+	}
+	return nil
+}
+
+// spanAttributesRecorder records the route template and the negotiated protocol version on the client span
+type spanAttributesRecorder struct {
+	next http.RoundTripper
+}
+
+func (s spanAttributesRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	span := trace.SpanFromContext(req.Context())
+	if route, ok := RouteFromContext(req.Context()); ok {
+		span.SetAttributes(RouteAttributeKey.String(route))
+	}
+
+	resp, err := s.next.RoundTrip(req)
+	if resp != nil {
+		version := strings.TrimPrefix(resp.Proto, "HTTP/")
+		if resp.ProtoMajor == 2 {
+			version = "2"
+		}
+		span.SetAttributes(protocolAttributeKey.String(version))
+	}
+	return resp, err
+}
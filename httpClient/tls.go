@@ -0,0 +1,205 @@
+package httpClient
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrCertificatePinMismatch returned when none of the server certificates matches the pinned SPKI hashes
+var ErrCertificatePinMismatch = errors.New("server certificate does not match any pinned SPKI hash")
+
+// TLSConfig different configurations to build the tls.Config of the HTTPClient
+type TLSConfig struct {
+	CAFile           string
+	CAPEM            []byte
+	CertFile         string // reloaded when the file changes
+	KeyFile          string // reloaded when the file changes
+	CertPEM          []byte
+	KeyPEM           []byte
+	MinVersion       uint16
+	ServerName       string
+	PinnedSPKIHashes []string // base64 encoded SHA-256 of the certificate's SubjectPublicKeyInfo
+}
+
+// TLSOption different options to configure NewTLSConfig
+type TLSOption func(config *TLSConfig)
+
+// WithTLSCAFile verifies the servers against the CA bundle at caFile instead of the system roots
+func WithTLSCAFile(caFile string) TLSOption {
+	return func(config *TLSConfig) {
+		config.CAFile = caFile
+	}
+}
+
+// WithTLSCAPEM verifies the servers against the PEM encoded CA bundle instead of the system roots
+func WithTLSCAPEM(caPEM []byte) TLSOption {
+	return func(config *TLSConfig) {
+		config.CAPEM = caPEM
+	}
+}
+
+// WithTLSClientCertFiles enables mTLS with the client certificate and key files, they are reloaded when rotated
+func WithTLSClientCertFiles(certFile, keyFile string) TLSOption {
+	return func(config *TLSConfig) {
+		config.CertFile = certFile
+		config.KeyFile = keyFile
+	}
+}
+
+// WithTLSClientCertPEM enables mTLS with the PEM encoded client certificate and key
+func WithTLSClientCertPEM(certPEM, keyPEM []byte) TLSOption {
+	return func(config *TLSConfig) {
+		config.CertPEM = certPEM
+		config.KeyPEM = keyPEM
+	}
+}
+
+// WithTLSMinVersion sets the minimum TLS version, e.g. tls.VersionTLS13
+func WithTLSMinVersion(v uint16) TLSOption {
+	return func(config *TLSConfig) {
+		config.MinVersion = v
+	}
+}
+
+// WithTLSServerName overrides the SNI and the name the server certificate is verified against
+func WithTLSServerName(name string) TLSOption {
+	return func(config *TLSConfig) {
+		config.ServerName = name
+	}
+}
+
+// WithTLSPinnedSPKI pins the server certificates by the base64 encoded SHA-256 of their SubjectPublicKeyInfo,
+// the connection is accepted if any certificate of a verified chain matches any of the hashes
+func WithTLSPinnedSPKI(hashes ...string) TLSOption {
+	return func(config *TLSConfig) {
+		config.PinnedSPKIHashes = append(config.PinnedSPKIHashes, hashes...)
+	}
+}
+
+// WithTLSConfig uses c as the TLS configuration of the client transport, see NewTLSConfig
+func WithTLSConfig(c *tls.Config) HTTPClientOption {
+	return func(client *HTTPClient) {
+		client.TransportConfig.TLSConfig = c
+	}
+}
+
+// SPKIHash returns the base64 encoded SHA-256 of the certificate's SubjectPublicKeyInfo to be used with WithTLSPinnedSPKI
+func SPKIHash(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// NewTLSConfig builds a tls.Config out of the options to be used with WithTLSConfig
+func NewTLSConfig(opts ...TLSOption) (*tls.Config, error) {
+	config := &TLSConfig{MinVersion: tls.VersionTLS12}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion: config.MinVersion,
+		ServerName: config.ServerName,
+	}
+
+	caPEM := config.CAPEM
+	if config.CAFile != "" {
+		b, err := os.ReadFile(config.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		caPEM = append(append([]byte{}, caPEM...), b...)
+	}
+	if len(caPEM) != 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("failed to parse CA certificates")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	switch {
+	case config.CertFile != "":
+		loader := &certFileLoader{certFile: config.CertFile, keyFile: config.KeyFile}
+		if _, err := loader.load(); err != nil {
+			return nil, err
+		}
+		tlsConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return loader.load()
+		}
+	case len(config.CertPEM) != 0:
+		cert, err := tls.X509KeyPair(config.CertPEM, config.KeyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if len(config.PinnedSPKIHashes) != 0 {
+		pins := make(map[string]struct{}, len(config.PinnedSPKIHashes))
+		for _, hash := range config.PinnedSPKIHashes {
+			pins[hash] = struct{}{}
+		}
+		tlsConfig.VerifyConnection = func(state tls.ConnectionState) error {
+			// only the verified chains are matched, the other certificates sent by the server are not verified hence could be
+			// any public certificate, e.g. the pinned one. Without verification, e.g. InsecureSkipVerify, only the leaf is matched
+			chains := state.VerifiedChains
+			if len(chains) == 0 && len(state.PeerCertificates) != 0 {
+				chains = [][]*x509.Certificate{state.PeerCertificates[:1]}
+			}
+			for _, chain := range chains {
+				for _, cert := range chain {
+					if _, ok := pins[SPKIHash(cert)]; ok {
+						return nil
+					}
+				}
+			}
+			return ErrCertificatePinMismatch
+		}
+	}
+
+	return tlsConfig, nil
+}
+
+// certFileLoader loads the client certificate and reloads it whenever the certificate or key file is modified
+type certFileLoader struct {
+	certFile string
+	keyFile  string
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	certMod time.Time
+	keyMod  time.Time
+}
+
+func (l *certFileLoader) load() (*tls.Certificate, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	certInfo, certErr := os.Stat(l.certFile)
+	keyInfo, keyErr := os.Stat(l.keyFile)
+	if certErr == nil && keyErr == nil && l.cert != nil &&
+		certInfo.ModTime().Equal(l.certMod) && keyInfo.ModTime().Equal(l.keyMod) {
+		return l.cert, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(l.certFile, l.keyFile)
+	if certErr != nil || keyErr != nil || err != nil {
+		// keep serving the previous certificate while the files are being rotated
+		if l.cert != nil {
+			return l.cert, nil
+		}
+		return nil, fmt.Errorf("failed to load client certificate: %w", errors.Join(certErr, keyErr, err))
+	}
+
+	l.cert = &cert
+	l.certMod = certInfo.ModTime()
+	l.keyMod = keyInfo.ModTime()
+	return l.cert, nil
+}
@@ -0,0 +1,219 @@
+package httpClient
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testCert struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+// newTestCert issues a certificate signed by parent, self signed CA if parent is nil
+func newTestCert(t *testing.T, parent *testCert, serial int64, name string) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{name},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+
+	signer, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return &testCert{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+// newMTLSServer starts a TLS server requiring client certificates signed by ca, it responds with the client certificate serial
+func newMTLSServer(t *testing.T, ca, serverCert *testCert) *httptest.Server {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+
+	cert, err := tls.X509KeyPair(serverCert.certPEM, serverCert.keyPEM)
+	require.NoError(t, err)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.TLS.PeerCertificates[0].SerialNumber.String()))
+	}))
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	return server
+}
+
+func clientCertSerial(t *testing.T, client *HTTPClient, url string) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	b := make([]byte, 32)
+	n, _ := resp.Body.Read(b)
+	return string(b[:n]), nil
+}
+
+func TestTLSConfigMutualTLS(t *testing.T) {
+	ca := newTestCert(t, nil, 1, "ca")
+	server := newMTLSServer(t, ca, newTestCert(t, ca, 2, "server.internal"))
+	clientCert := newTestCert(t, ca, 3, "client")
+
+	tlsConfig, err := NewTLSConfig(
+		WithTLSCAPEM(ca.certPEM),
+		WithTLSClientCertPEM(clientCert.certPEM, clientCert.keyPEM),
+		WithTLSMinVersion(tls.VersionTLS13),
+		WithTLSServerName("server.internal"),
+	)
+	require.NoError(t, err)
+
+	client := NewHTTPClient(WithTLSConfig(tlsConfig))
+	serial, err := clientCertSerial(t, client, server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "3", serial)
+
+	// without the client certificate the handshake fails
+	tlsConfig, err = NewTLSConfig(WithTLSCAPEM(ca.certPEM))
+	require.NoError(t, err)
+	_, err = clientCertSerial(t, NewHTTPClient(WithTLSConfig(tlsConfig)), server.URL)
+	assert.Error(t, err)
+}
+
+func TestTLSConfigClientCertReload(t *testing.T) {
+	ca := newTestCert(t, nil, 1, "ca")
+	server := newMTLSServer(t, ca, newTestCert(t, ca, 2, "server.internal"))
+
+	dir := t.TempDir()
+	caFile, certFile, keyFile := filepath.Join(dir, "ca.pem"), filepath.Join(dir, "client.pem"), filepath.Join(dir, "client-key.pem")
+	writeCert := func(c *testCert, modTime time.Time) {
+		require.NoError(t, os.WriteFile(certFile, c.certPEM, 0o600))
+		require.NoError(t, os.WriteFile(keyFile, c.keyPEM, 0o600))
+		require.NoError(t, os.Chtimes(certFile, modTime, modTime))
+		require.NoError(t, os.Chtimes(keyFile, modTime, modTime))
+	}
+	require.NoError(t, os.WriteFile(caFile, ca.certPEM, 0o600))
+	writeCert(newTestCert(t, ca, 3, "client"), time.Now().Add(-time.Minute))
+
+	tlsConfig, err := NewTLSConfig(WithTLSCAFile(caFile), WithTLSClientCertFiles(certFile, keyFile), WithTLSServerName("server.internal"))
+	require.NoError(t, err)
+	client := NewHTTPClient(WithTLSConfig(tlsConfig), WithIdleConnTimeout(time.Millisecond))
+
+	serial, err := clientCertSerial(t, client, server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "3", serial)
+
+	writeCert(newTestCert(t, ca, 4, "client"), time.Now())
+	client.Client.CloseIdleConnections()
+
+	serial, err = clientCertSerial(t, client, server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "4", serial)
+}
+
+func TestTLSConfigPinning(t *testing.T) {
+	ca := newTestCert(t, nil, 1, "ca")
+	serverCert := newTestCert(t, ca, 2, "server.internal")
+	server := newMTLSServer(t, ca, serverCert)
+	clientCert := newTestCert(t, ca, 3, "client")
+
+	// a valid leaf followed by the pinned certificate, which is public, as an extra unverified chain entry
+	pinned := newTestCert(t, nil, 4, "pinned")
+	padded := newMTLSServer(t, ca, &testCert{
+		cert:    serverCert.cert,
+		key:     serverCert.key,
+		certPEM: append(append([]byte{}, serverCert.certPEM...), pinned.certPEM...),
+		keyPEM:  serverCert.keyPEM,
+	})
+
+	testCases := []struct {
+		name          string
+		pin           string
+		server        *httptest.Server
+		expectedError bool
+	}{
+		{name: "pinned server certificate", pin: SPKIHash(serverCert.cert), server: server},
+		{name: "pinned CA certificate", pin: SPKIHash(ca.cert), server: server},
+		{name: "pin mismatch", pin: SPKIHash(clientCert.cert), server: server, expectedError: true},
+		{name: "pinned certificate sent outside the verified chain", pin: SPKIHash(pinned.cert), server: padded, expectedError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tlsConfig, err := NewTLSConfig(
+				WithTLSCAPEM(ca.certPEM),
+				WithTLSClientCertPEM(clientCert.certPEM, clientCert.keyPEM),
+				WithTLSServerName("server.internal"),
+				WithTLSPinnedSPKI(tc.pin),
+			)
+			require.NoError(t, err)
+
+			_, err = clientCertSerial(t, NewHTTPClient(WithTLSConfig(tlsConfig)), tc.server.URL)
+			if tc.expectedError {
+				assert.ErrorIs(t, err, ErrCertificatePinMismatch)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestNewTLSConfigErrors(t *testing.T) {
+	_, err := NewTLSConfig(WithTLSCAFile("testdata/missing.pem"))
+	assert.Error(t, err)
+
+	_, err = NewTLSConfig(WithTLSCAPEM([]byte("not a certificate")))
+	assert.Error(t, err)
+
+	_, err = NewTLSConfig(WithTLSClientCertFiles("testdata/missing.pem", "testdata/missing-key.pem"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,156 @@
+package httpClient
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+)
+
+// TransportConfig different configurations of the transport owned by the HTTPClient,
+// zero values keep the http.DefaultTransport ones
+type TransportConfig struct {
+	MaxIdleConns          int
+	MaxIdleConnsPerHost   int
+	MaxConnsPerHost       int
+	IdleConnTimeout       time.Duration
+	DialTimeout           time.Duration
+	KeepAlive             time.Duration
+	TLSHandshakeTimeout   time.Duration
+	ResponseHeaderTimeout time.Duration
+	ExpectContinueTimeout time.Duration
+	TLSConfig             *tls.Config
+	Proxy                 ProxyConfig
+	DialContext           DialContextFunc
+	UnixSocket            string
+	HTTP2                 HTTP2Config
+}
+
+// default dialer settings of http.DefaultTransport
+const (
+	defaultDialTimeout = 30 * time.Second
+	defaultKeepAlive   = 30 * time.Second
+)
+
+// WithMaxIdleConns sets the maximum number of idle connections across all hosts
+func WithMaxIdleConns(n int) HTTPClientOption {
+	return func(client *HTTPClient) {
+		client.TransportConfig.MaxIdleConns = n
+	}
+}
+
+// WithMaxIdleConnsPerHost sets the maximum number of idle connections per host, http.DefaultTransport keeps only 2
+func WithMaxIdleConnsPerHost(n int) HTTPClientOption {
+	return func(client *HTTPClient) {
+		client.TransportConfig.MaxIdleConnsPerHost = n
+	}
+}
+
+// WithMaxConnsPerHost limits the total number of connections per host, including the ones in use
+func WithMaxConnsPerHost(n int) HTTPClientOption {
+	return func(client *HTTPClient) {
+		client.TransportConfig.MaxConnsPerHost = n
+	}
+}
+
+// WithIdleConnTimeout sets how long an idle connection is kept in the pool
+func WithIdleConnTimeout(t time.Duration) HTTPClientOption {
+	return func(client *HTTPClient) {
+		client.TransportConfig.IdleConnTimeout = t
+	}
+}
+
+// WithDialTimeout sets the timeout for establishing the TCP connection
+func WithDialTimeout(t time.Duration) HTTPClientOption {
+	return func(client *HTTPClient) {
+		client.TransportConfig.DialTimeout = t
+	}
+}
+
+// WithKeepAlive sets the TCP keep-alive period of the connections
+func WithKeepAlive(t time.Duration) HTTPClientOption {
+	return func(client *HTTPClient) {
+		client.TransportConfig.KeepAlive = t
+	}
+}
+
+// WithTLSHandshakeTimeout sets the timeout for the TLS handshake
+func WithTLSHandshakeTimeout(t time.Duration) HTTPClientOption {
+	return func(client *HTTPClient) {
+		client.TransportConfig.TLSHandshakeTimeout = t
+	}
+}
+
+// WithResponseHeaderTimeout sets the timeout for reading the response headers once the request is written
+func WithResponseHeaderTimeout(t time.Duration) HTTPClientOption {
+	return func(client *HTTPClient) {
+		client.TransportConfig.ResponseHeaderTimeout = t
+	}
+}
+
+// WithExpectContinueTimeout sets how long to wait for a 100-continue response when the request has "Expect: 100-continue"
+func WithExpectContinueTimeout(t time.Duration) HTTPClientOption {
+	return func(client *HTTPClient) {
+		client.TransportConfig.ExpectContinueTimeout = t
+	}
+}
+
+// newTransport clones http.DefaultTransport so that every HTTPClient owns its connection pool and applies the TransportConfig
+func (c *HTTPClient) newTransport() http.RoundTripper {
+	config := c.TransportConfig
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	dialer := &net.Dialer{Timeout: defaultDialTimeout, KeepAlive: defaultKeepAlive}
+	if config.DialTimeout != 0 {
+		dialer.Timeout = config.DialTimeout
+	}
+	if config.KeepAlive != 0 {
+		dialer.KeepAlive = config.KeepAlive
+	}
+
+	switch {
+	case config.DialContext != nil:
+		transport.DialContext = config.DialContext
+	case config.UnixSocket != "":
+		transport.DialContext = unixSocketDialer(dialer, config.UnixSocket)
+	case config.DialTimeout != 0 || config.KeepAlive != 0:
+		transport.DialContext = dialer.DialContext
+	}
+
+	if config.MaxIdleConns != 0 {
+		transport.MaxIdleConns = config.MaxIdleConns
+	}
+	if config.MaxIdleConnsPerHost != 0 {
+		transport.MaxIdleConnsPerHost = config.MaxIdleConnsPerHost
+	}
+	if config.MaxConnsPerHost != 0 {
+		transport.MaxConnsPerHost = config.MaxConnsPerHost
+	}
+	if config.IdleConnTimeout != 0 {
+		transport.IdleConnTimeout = config.IdleConnTimeout
+	}
+	if config.TLSHandshakeTimeout != 0 {
+		transport.TLSHandshakeTimeout = config.TLSHandshakeTimeout
+	}
+	if config.ResponseHeaderTimeout != 0 {
+		transport.ResponseHeaderTimeout = config.ResponseHeaderTimeout
+	}
+	if config.ExpectContinueTimeout != 0 {
+		transport.ExpectContinueTimeout = config.ExpectContinueTimeout
+	}
+	if config.TLSConfig != nil {
+		transport.TLSClientConfig = config.TLSConfig.Clone()
+	}
+	if config.Proxy.configured() {
+		transport.Proxy = config.Proxy.proxyFunc()
+	}
+	if config.UnixSocket != "" {
+		transport.Proxy = nil
+	}
+	if config.Proxy.ConnectHeaders != nil {
+		transport.ProxyConnectHeader = config.Proxy.ConnectHeaders.Clone()
+	}
+
+	config.reportH2CIgnored()
+	return config.HTTP2.configureHTTP2(transport)
+}
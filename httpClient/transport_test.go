@@ -0,0 +1,49 @@
+package httpClient
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHTTPClientTransport(t *testing.T) {
+	client := NewHTTPClient(
+		WithMaxIdleConns(200),
+		WithMaxIdleConnsPerHost(50),
+		WithMaxConnsPerHost(100),
+		WithIdleConnTimeout(time.Minute),
+		WithDialTimeout(time.Second),
+		WithKeepAlive(15*time.Second),
+		WithTLSHandshakeTimeout(2*time.Second),
+		WithResponseHeaderTimeout(3*time.Second),
+		WithExpectContinueTimeout(500*time.Millisecond),
+	)
+
+	transport, ok := client.Client.Transport.(*http.Transport)
+	require.True(t, ok)
+	assert.NotSame(t, http.DefaultTransport, transport)
+
+	assert.Equal(t, 200, transport.MaxIdleConns)
+	assert.Equal(t, 50, transport.MaxIdleConnsPerHost)
+	assert.Equal(t, 100, transport.MaxConnsPerHost)
+	assert.Equal(t, time.Minute, transport.IdleConnTimeout)
+	assert.Equal(t, 2*time.Second, transport.TLSHandshakeTimeout)
+	assert.Equal(t, 3*time.Second, transport.ResponseHeaderTimeout)
+	assert.Equal(t, 500*time.Millisecond, transport.ExpectContinueTimeout)
+	assert.NotNil(t, transport.DialContext)
+}
+
+func TestNewHTTPClientOwnsTransport(t *testing.T) {
+	first := NewHTTPClient()
+	second := NewHTTPClient()
+
+	defaults := http.DefaultTransport.(*http.Transport)
+	transport := first.Client.Transport.(*http.Transport)
+	assert.NotSame(t, defaults, transport)
+	assert.NotSame(t, second.Client.Transport, transport)
+	assert.Equal(t, defaults.MaxIdleConns, transport.MaxIdleConns)
+	assert.Equal(t, defaults.IdleConnTimeout, transport.IdleConnTimeout)
+}
@@ -0,0 +1,341 @@
+package rustic
+
+import (
+	"encoding"
+	"fmt"
+	netUrl "net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// QueryMarshaler custom encoding of a value into a query or form value
+type QueryMarshaler interface {
+	MarshalQuery() (string, error)
+}
+
+var (
+	timeType           = reflect.TypeOf(time.Time{})
+	queryMarshalerType = reflect.TypeOf((*QueryMarshaler)(nil)).Elem()
+	textMarshalerType  = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// queryTagOptions options of the `query:"name,opt1,opt2"` struct tag
+type queryTagOptions struct {
+	omitEmpty bool // skips zero values and nil pointers
+	comma     bool // encodes slices as a single comma separated value instead of repeating the key
+	brackets  bool // encodes nested structs and maps as parent[child] instead of parent.child
+	unix      bool // encodes time.Time as unix seconds
+	unixMilli bool // encodes time.Time as unix milliseconds
+}
+
+func parseQueryTag(tag string) (string, queryTagOptions) {
+	name, rest, _ := strings.Cut(tag, ",")
+
+	var opts queryTagOptions
+	for _, opt := range strings.Split(rest, ",") {
+		switch opt {
+		case "omitempty":
+			opts.omitEmpty = true
+		case "comma":
+			opts.comma = true
+		case "brackets":
+			opts.brackets = true
+		case "unix":
+			opts.unix = true
+		case "unixmilli":
+			opts.unixMilli = true
+		}
+	}
+
+	return name, opts
+}
+
+// EncodeQuery encodes a struct into url.Values using its `query:"name,omitempty"` tags, url.Values and map[string]string are copied as is.
+//
+// Untagged exported fields use the field name, `query:"-"` skips the field and embedded structs are flattened.
+// Slices repeat the key, or with the comma option are joined in a single value. time.Time uses the `layout:"..."` tag,
+// RFC3339 by default, or unix/unixmilli options. Pointers are dereferenced, nil pointers are skipped.
+// Nested structs and maps use dot notation, parent.child, or with the brackets option parent[child].
+// Values implementing QueryMarshaler or encoding.TextMarshaler encode themselves
+func EncodeQuery(v any) (netUrl.Values, error) {
+	values := netUrl.Values{}
+
+	switch q := v.(type) {
+	case nil:
+		return values, nil
+	case netUrl.Values:
+		for key, vs := range q {
+			values[key] = append([]string(nil), vs...)
+		}
+		return values, nil
+	case map[string]string:
+		for key, value := range q {
+			values.Set(key, value)
+		}
+		return values, nil
+	}
+
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return values, nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("failed to encode query: expected a struct, got %T", v)
+	}
+
+	if err := encodeQueryStruct(values, "", false, rv); err != nil {
+		return nil, fmt.Errorf("failed to encode query: %w", err)
+	}
+	return values, nil
+}
+
+func queryKey(prefix, name string, brackets bool) string {
+	switch {
+	case prefix == "":
+		return name
+	case brackets:
+		return prefix + "[" + name + "]"
+	default:
+		return prefix + "." + name
+	}
+}
+
+func encodeQueryStruct(values netUrl.Values, prefix string, brackets bool, rv reflect.Value) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		tag := field.Tag.Get("query")
+		if tag == "-" {
+			continue
+		}
+		name, opts := parseQueryTag(tag)
+
+		fv := rv.Field(i)
+		if field.Anonymous && name == "" {
+			embedded := fv
+			for embedded.Kind() == reflect.Pointer && !embedded.IsNil() {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct && !isQueryScalar(embedded) {
+				if err := encodeQueryStruct(values, prefix, brackets, embedded); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if opts.omitEmpty && fv.IsZero() {
+			continue
+		}
+
+		key := queryKey(prefix, name, brackets)
+		if err := encodeQueryValue(values, key, brackets || opts.brackets, fv, opts, field.Tag.Get("layout")); err != nil {
+			return fmt.Errorf("field %s: %w", field.Name, err)
+		}
+	}
+	return nil
+}
+
+func encodeQueryValue(values netUrl.Values, key string, brackets bool, rv reflect.Value, opts queryTagOptions, layout string) error {
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+
+	if s, ok, err := encodeQueryScalar(rv, opts, layout); ok || err != nil {
+		if err != nil {
+			return err
+		}
+		values.Add(key, s)
+		return nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		items := make([]string, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			item := rv.Index(i)
+			for item.Kind() == reflect.Pointer || item.Kind() == reflect.Interface {
+				if item.IsNil() {
+					break
+				}
+				item = item.Elem()
+			}
+			if item.Kind() == reflect.Pointer || item.Kind() == reflect.Interface {
+				continue
+			}
+			s, ok, err := encodeQueryScalar(item, opts, layout)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return fmt.Errorf("unsupported slice element type %s", item.Type())
+			}
+			items = append(items, s)
+		}
+		if opts.comma {
+			values.Add(key, strings.Join(items, ","))
+			return nil
+		}
+		for _, item := range items {
+			values.Add(key, item)
+		}
+		return nil
+	case reflect.Struct:
+		return encodeQueryStruct(values, key, brackets, rv)
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("unsupported map key type %s", rv.Type().Key())
+		}
+		keys := rv.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		for _, k := range keys {
+			if err := encodeQueryValue(values, queryKey(key, k.String(), brackets), brackets, rv.MapIndex(k), opts, layout); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported type %s", rv.Type())
+	}
+}
+
+// isQueryScalar reports whether the value is encoded as a single value
+func isQueryScalar(rv reflect.Value) bool {
+	t := rv.Type()
+	return t == timeType || t.Implements(queryMarshalerType) || t.Implements(textMarshalerType) ||
+		reflect.PointerTo(t).Implements(queryMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType)
+}
+
+// encodeQueryScalar encodes a single value, ok is false if rv is not a scalar
+func encodeQueryScalar(rv reflect.Value, opts queryTagOptions, layout string) (string, bool, error) {
+	if rv.Type() == timeType {
+		t := rv.Interface().(time.Time)
+		switch {
+		case opts.unix:
+			return strconv.FormatInt(t.Unix(), 10), true, nil
+		case opts.unixMilli:
+			return strconv.FormatInt(t.UnixMilli(), 10), true, nil
+		case layout != "":
+			return t.Format(layout), true, nil
+		default:
+			return t.Format(time.RFC3339), true, nil
+		}
+	}
+
+	if !rv.CanAddr() {
+		// make pointer receivers reachable
+		addressable := reflect.New(rv.Type()).Elem()
+		addressable.Set(rv)
+		rv = addressable
+	}
+	for _, candidate := range []reflect.Value{rv, rv.Addr()} {
+		if !candidate.CanInterface() {
+			continue
+		}
+		switch m := candidate.Interface().(type) {
+		case QueryMarshaler:
+			s, err := m.MarshalQuery()
+			return s, true, err
+		case encoding.TextMarshaler:
+			b, err := m.MarshalText()
+			return string(b), true, err
+		}
+	}
+
+	switch rv.Kind() {
+	case reflect.String:
+		return rv.String(), true, nil
+	case reflect.Bool:
+		return strconv.FormatBool(rv.Bool()), true, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), true, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10), true, nil
+	case reflect.Float32:
+		return strconv.FormatFloat(rv.Float(), 'f', -1, 32), true, nil
+	case reflect.Float64:
+		return strconv.FormatFloat(rv.Float(), 'f', -1, 64), true, nil
+	default:
+		return "", false, nil
+	}
+}
+
+// mergeValues adds the values encoded out of v to a copy of params
+func mergeValues(params netUrl.Values, v any) (netUrl.Values, error) {
+	values := netUrl.Values{}
+	for key, vs := range params {
+		values[key] = append([]string(nil), vs...)
+	}
+	if v == nil {
+		return values, nil
+	}
+
+	encoded, err := EncodeQuery(v)
+	if err != nil {
+		return nil, err
+	}
+	for key, vs := range encoded {
+		values[key] = append(values[key], vs...)
+	}
+	return values, nil
+}
+
+// QueryPrecedence how the query params are merged with the query string already in the URL
+type QueryPrecedence int
+
+const (
+	// QueryMerge keeps the values of both, the ones of the URL first
+	QueryMerge QueryPrecedence = iota
+	// QueryOverride replaces the values of the URL with the query params sharing the key
+	QueryOverride
+	// QueryKeepExisting ignores the query params whose key is already in the URL
+	QueryKeepExisting
+)
+
+// buildURL merges the query params, including the ones encoded by WithQuery, into the query string of url
+func buildURL(url string, config *HTTPConfig) (string, error) {
+	parsedURL, err := netUrl.Parse(url)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse url: %w", err)
+	}
+
+	params, err := mergeValues(config.QueryParams, config.Query)
+	if err != nil {
+		return "", err
+	}
+	if len(params) == 0 {
+		return url, nil
+	}
+
+	existing, err := netUrl.ParseQuery(parsedURL.RawQuery)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse query of url: %w", err)
+	}
+
+	for key, values := range params {
+		_, exists := existing[key]
+		switch {
+		case !exists || config.QueryPrecedence == QueryOverride:
+			existing[key] = values
+		case config.QueryPrecedence == QueryMerge:
+			existing[key] = append(existing[key], values...)
+		}
+	}
+
+	parsedURL.RawQuery = existing.Encode()
+	return parsedURL.String(), nil
+}
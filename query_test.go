@@ -0,0 +1,207 @@
+package rustic
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/rag594/rustic/httpClient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testSort struct {
+	Field string
+	Desc  bool
+}
+
+func (s testSort) MarshalQuery() (string, error) {
+	if s.Desc {
+		return "-" + s.Field, nil
+	}
+	return s.Field, nil
+}
+
+type testPage struct {
+	Page  int `query:"page,omitempty"`
+	Limit int `query:"limit,omitempty"`
+}
+
+type testFilter struct {
+	Name   string   `query:"name,omitempty"`
+	Labels []string `query:"labels,omitempty"`
+}
+
+type testSearch struct {
+	testPage
+	Query    string            `query:"q"`
+	Tags     []string          `query:"tags"`
+	IDs      []int             `query:"ids,comma"`
+	Active   *bool             `query:"active,omitempty"`
+	Missing  *string           `query:"missing"`
+	Since    time.Time         `query:"since" layout:"2006-01-02"`
+	Until    time.Time         `query:"until,unix"`
+	Created  time.Time         `query:"created,omitempty"`
+	Filter   testFilter        `query:"filter"`
+	Meta     map[string]string `query:"meta,brackets"`
+	Sort     testSort          `query:"sort"`
+	Secret   string            `query:"-"`
+	Untagged float64
+	internal string
+}
+
+func TestEncodeQuery(t *testing.T) {
+	active := true
+	since := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	values, err := EncodeQuery(&testSearch{
+		testPage: testPage{Page: 2},
+		Query:    "go http",
+		Tags:     []string{"a", "b"},
+		IDs:      []int{1, 2, 3},
+		Active:   &active,
+		Since:    since,
+		Until:    since,
+		Filter:   testFilter{Name: "john", Labels: []string{"x"}},
+		Meta:     map[string]string{"k": "v"},
+		Sort:     testSort{Field: "created", Desc: true},
+		Secret:   "s3cr3t",
+		Untagged: 1.5,
+		internal: "internal",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, url.Values{
+		"page":          {"2"},
+		"q":             {"go http"},
+		"tags":          {"a", "b"},
+		"ids":           {"1,2,3"},
+		"active":        {"true"},
+		"since":         {"2024-03-01"},
+		"until":         {"1709287200"},
+		"filter.name":   {"john"},
+		"filter.labels": {"x"},
+		"meta[k]":       {"v"},
+		"sort":          {"-created"},
+		"Untagged":      {"1.5"},
+	}, values)
+}
+
+func TestEncodeQueryErrors(t *testing.T) {
+	_, err := EncodeQuery("not a struct")
+	assert.ErrorContains(t, err, "expected a struct")
+
+	_, err = EncodeQuery(struct {
+		Ch chan int `query:"ch"`
+	}{Ch: make(chan int)})
+	assert.ErrorContains(t, err, "field Ch")
+
+	values, err := EncodeQuery((*testSearch)(nil))
+	require.NoError(t, err)
+	assert.Empty(t, values)
+}
+
+func TestWithQueryAndForm(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		if r.Method == http.MethodGet {
+			assert.Equal(t, url.Values{"q": {"go"}, "tags": {"a", "b"}, "page": {"1"}}, r.Form)
+		} else {
+			assert.Equal(t, []string{"go"}, r.PostForm["q"])
+			assert.Equal(t, []string{"2"}, r.PostForm["page"])
+			assert.Equal(t, []string{"x"}, r.PostForm["extra"])
+		}
+		w.WriteHeader(http.StatusOK)
+		err := json.NewEncoder(w).Encode(TestResponse{ID: 1})
+		require.NoError(t, err)
+	}))
+	t.Cleanup(server.Close)
+
+	type search struct {
+		Query string   `query:"q"`
+		Tags  []string `query:"tags,omitempty"`
+		Page  int      `query:"page,omitempty"`
+	}
+
+	client := httpClient.NewHTTPClient()
+
+	_, err := GET[TestResponse](context.Background(), server.URL,
+		WithHttpClient(client),
+		WithQueryParams(url.Values{"page": {"1"}}),
+		WithQuery(search{Query: "go", Tags: []string{"a", "b"}}),
+	)
+	require.NoError(t, err)
+
+	_, err = POSTFormData[TestResponse](context.Background(), server.URL,
+		WithHttpClient(client),
+		WithFormParams(url.Values{"extra": {"x"}}),
+		WithForm(&search{Query: "go", Page: 2}),
+	)
+	require.NoError(t, err)
+
+	_, err = GET[TestResponse](context.Background(), server.URL, WithHttpClient(client), WithQuery([]string{"invalid"}))
+	assert.ErrorContains(t, err, "failed to encode query")
+}
+
+func TestBuildURL(t *testing.T) {
+	params := url.Values{"page": {"2"}, "sort": {"name"}}
+
+	tests := []struct {
+		name       string
+		url        string
+		precedence QueryPrecedence
+		expected   string
+	}{
+		{name: "no existing query", url: "http://localhost/users", expected: "http://localhost/users?page=2&sort=name"},
+		{name: "merge", url: "http://localhost/users?page=1&q=go", precedence: QueryMerge, expected: "http://localhost/users?page=1&page=2&q=go&sort=name"},
+		{name: "override", url: "http://localhost/users?page=1&q=go", precedence: QueryOverride, expected: "http://localhost/users?page=2&q=go&sort=name"},
+		{name: "keep existing", url: "http://localhost/users?page=1&q=go", precedence: QueryKeepExisting, expected: "http://localhost/users?page=1&q=go&sort=name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := buildURL(tt.url, &HTTPConfig{QueryParams: params, QueryPrecedence: tt.precedence})
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+
+	got, err := buildURL("http://localhost/users?b=2&a=1", &HTTPConfig{})
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost/users?b=2&a=1", got, "the url is kept as is without query params")
+
+	_, err = buildURL("http://local host/%zz", &HTTPConfig{QueryParams: params})
+	assert.ErrorContains(t, err, "failed to parse url")
+}
+
+func TestQueryParamsOnEveryVerb(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "a=1&a=2&b=3", r.URL.RawQuery, r.Method)
+		w.WriteHeader(http.StatusOK)
+		err := json.NewEncoder(w).Encode(TestResponse{ID: 1})
+		require.NoError(t, err)
+	}))
+	t.Cleanup(server.Close)
+
+	client := httpClient.NewHTTPClient()
+	opts := []HTTPConfigOptions{WithHttpClient(client), WithQueryParams(url.Values{"a": {"2"}, "b": {"3"}})}
+	ctx := context.Background()
+
+	_, err := GET[TestResponse](ctx, server.URL+"?a=1", opts...)
+	require.NoError(t, err)
+	_, err = POST[TestRequest, TestResponse](ctx, server.URL+"?a=1", &TestRequest{}, opts...)
+	require.NoError(t, err)
+	_, err = PUT[TestRequest, TestResponse](ctx, server.URL+"?a=1", &TestRequest{}, opts...)
+	require.NoError(t, err)
+	_, err = POSTFormData[TestResponse](ctx, server.URL+"?a=1", opts...)
+	require.NoError(t, err)
+	_, err = POSTMultiPartFormData[TestResponse](ctx, server.URL+"?a=1", nil, opts...)
+	require.NoError(t, err)
+
+	_, err = GET[TestResponse](ctx, "://invalid", opts...)
+	assert.ErrorContains(t, err, "failed to parse url")
+}
@@ -0,0 +1,30 @@
+package rusticTracer
+
+import (
+	"net/http"
+
+	"github.com/felixge/httpsnoop"
+	"github.com/go-chi/chi/v5"
+)
+
+// ChiTracerMiddleware extracts and injects the trace for incoming chi requests to be propagated forward,
+// it has to be registered with Router.Use so that the route pattern is resolved
+func ChiTracerMiddleware(service string, opts ...MiddlewareOption) func(http.Handler) http.Handler {
+	st := newServerTracer(service, opts...)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			req, span := st.start(r)
+
+			m := httpsnoop.CaptureMetrics(next, w, req)
+
+			// chi resolves the route pattern while routing, hence it is read after serving the request
+			var route string
+			if rctx := chi.RouteContext(req.Context()); rctx != nil {
+				route = rctx.RoutePattern()
+			}
+
+			st.end(span, route, m.Code, nil)
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package rusticTracer
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	stdoutTrace "go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"google.golang.org/grpc/credentials"
+)
+
+// OTLPConfig different configurations for the OTLP exporters
+type OTLPConfig struct {
+	TLSConfig   *tls.Config
+	CAFile      string
+	CAPEM       []byte
+	CertFile    string
+	KeyFile     string
+	Headers     map[string]string
+	Compression bool
+	URLPath     string // only used by the OTLP/HTTP exporter
+	Timeout     time.Duration
+}
+
+// OTLPOption different options to configure the OTLP exporters
+type OTLPOption func(config *OTLPConfig)
+
+// WithOTLPTLSConfig enables TLS with the given tls.Config, CA and client certificate options are applied on top of it
+func WithOTLPTLSConfig(c *tls.Config) OTLPOption {
+	return func(config *OTLPConfig) {
+		config.TLSConfig = c
+	}
+}
+
+// WithOTLPCAFile enables TLS and verifies the collector against the CA bundle at caFile
+func WithOTLPCAFile(caFile string) OTLPOption {
+	return func(config *OTLPConfig) {
+		config.CAFile = caFile
+	}
+}
+
+// WithOTLPCAPEM enables TLS and verifies the collector against the PEM encoded CA bundle
+func WithOTLPCAPEM(caPEM []byte) OTLPOption {
+	return func(config *OTLPConfig) {
+		config.CAPEM = caPEM
+	}
+}
+
+// WithOTLPClientCert enables mTLS with the client certificate and key files
+func WithOTLPClientCert(certFile, keyFile string) OTLPOption {
+	return func(config *OTLPConfig) {
+		config.CertFile = certFile
+		config.KeyFile = keyFile
+	}
+}
+
+// WithOTLPHeaders sends the headers with every export, e.g. vendor API keys
+func WithOTLPHeaders(headers map[string]string) OTLPOption {
+	return func(config *OTLPConfig) {
+		config.Headers = headers
+	}
+}
+
+// WithOTLPCompression enables gzip compression of the exported spans
+func WithOTLPCompression() OTLPOption {
+	return func(config *OTLPConfig) {
+		config.Compression = true
+	}
+}
+
+// WithOTLPURLPath overrides the default /v1/traces path of the OTLP/HTTP exporter
+func WithOTLPURLPath(path string) OTLPOption {
+	return func(config *OTLPConfig) {
+		config.URLPath = path
+	}
+}
+
+// WithOTLPTimeout sets the timeout of each export
+func WithOTLPTimeout(t time.Duration) OTLPOption {
+	return func(config *OTLPConfig) {
+		config.Timeout = t
+	}
+}
+
+// tlsEnabled reports whether any TLS option is configured, otherwise the exporter is insecure
+func (c *OTLPConfig) tlsEnabled() bool {
+	return c.TLSConfig != nil || c.CAFile != "" || len(c.CAPEM) != 0 || c.CertFile != ""
+}
+
+// buildTLSConfig builds the tls.Config out of the configured CA and client certificate
+func (c *OTLPConfig) buildTLSConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if c.TLSConfig != nil {
+		tlsConfig = c.TLSConfig.Clone()
+	}
+
+	caPEM := c.CAPEM
+	if c.CAFile != "" {
+		b, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		caPEM = append(caPEM, b...)
+	}
+
+	if len(caPEM) != 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("failed to parse CA certificates")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if c.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = append(tlsConfig.Certificates, cert)
+	}
+
+	return tlsConfig, nil
+}
+
+// StdOutExporter outputs the traces to the stdout
+func StdOutExporter() (*stdoutTrace.Exporter, error) {
+	stdOutExporter, err := stdoutTrace.New(stdoutTrace.WithPrettyPrint())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create exporter: %w", err)
+	}
+
+	return stdOutExporter, nil
+}
+
+// OTLPExporter Uses OpenTelemetry’s standard OTLP/HTTP with host/port, insecure unless a TLS option is configured
+func OTLPExporter(host, port string, opts ...OTLPOption) (*otlptrace.Exporter, error) {
+	config := &OTLPConfig{}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	httpOpts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(net.JoinHostPort(host, port))}
+	if config.tlsEnabled() {
+		tlsConfig, err := config.buildTLSConfig()
+		if err != nil {
+			return nil, err
+		}
+		httpOpts = append(httpOpts, otlptracehttp.WithTLSClientConfig(tlsConfig))
+	} else {
+		httpOpts = append(httpOpts, otlptracehttp.WithInsecure())
+	}
+	if len(config.Headers) != 0 {
+		httpOpts = append(httpOpts, otlptracehttp.WithHeaders(config.Headers))
+	}
+	if config.Compression {
+		httpOpts = append(httpOpts, otlptracehttp.WithCompression(otlptracehttp.GzipCompression))
+	}
+	if config.URLPath != "" {
+		httpOpts = append(httpOpts, otlptracehttp.WithURLPath(config.URLPath))
+	}
+	if config.Timeout != 0 {
+		httpOpts = append(httpOpts, otlptracehttp.WithTimeout(config.Timeout))
+	}
+
+	// Create an OTLP exporter (send data to OpenTelemetry collector)
+	oltpExporter, err := otlptracehttp.New(context.Background(), httpOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create exporter: %w", err)
+	}
+
+	return oltpExporter, nil
+}
+
+// OTLPGRPCExporter Uses OpenTelemetry’s standard OTLP/gRPC with host/port, insecure unless a TLS option is configured
+func OTLPGRPCExporter(host, port string, opts ...OTLPOption) (*otlptrace.Exporter, error) {
+	config := &OTLPConfig{}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	grpcOpts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(net.JoinHostPort(host, port))}
+	if config.tlsEnabled() {
+		tlsConfig, err := config.buildTLSConfig()
+		if err != nil {
+			return nil, err
+		}
+		grpcOpts = append(grpcOpts, otlptracegrpc.WithTLSCredentials(credentials.NewTLS(tlsConfig)))
+	} else {
+		grpcOpts = append(grpcOpts, otlptracegrpc.WithInsecure())
+	}
+	if len(config.Headers) != 0 {
+		grpcOpts = append(grpcOpts, otlptracegrpc.WithHeaders(config.Headers))
+	}
+	if config.Compression {
+		grpcOpts = append(grpcOpts, otlptracegrpc.WithCompressor("gzip"))
+	}
+	if config.Timeout != 0 {
+		grpcOpts = append(grpcOpts, otlptracegrpc.WithTimeout(config.Timeout))
+	}
+
+	oltpExporter, err := otlptracegrpc.New(context.Background(), grpcOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create exporter: %w", err)
+	}
+
+	return oltpExporter, nil
+}
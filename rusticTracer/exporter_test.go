@@ -0,0 +1,116 @@
+package rusticTracer
+
+import (
+	"context"
+	"encoding/pem"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	collectorTrace "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func testSpans() tracetest.SpanStubs {
+	return tracetest.SpanStubs{{Name: "test-span"}}
+}
+
+func hostPort(t *testing.T, rawURL string) (string, string) {
+	u, err := url.Parse(rawURL)
+	require.NoError(t, err)
+	host, port, err := net.SplitHostPort(u.Host)
+	require.NoError(t, err)
+	return host, port
+}
+
+func TestOTLPExporter(t *testing.T) {
+	received := make(chan *http.Request, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		received <- r
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+
+	host, port := hostPort(t, server.URL)
+	exporter, err := OTLPExporter(host, port,
+		WithOTLPHeaders(map[string]string{"x-api-key": "secret"}),
+		WithOTLPCompression(),
+		WithOTLPURLPath("/custom/traces"),
+	)
+	require.NoError(t, err)
+
+	require.NoError(t, exporter.ExportSpans(context.Background(), testSpans().Snapshots()))
+	require.NoError(t, exporter.Shutdown(context.Background()))
+
+	r := <-received
+	assert.Equal(t, "/custom/traces", r.URL.Path)
+	assert.Equal(t, "secret", r.Header.Get("x-api-key"))
+	assert.Equal(t, "gzip", r.Header.Get("Content-Encoding"))
+}
+
+func TestOTLPExporterTLS(t *testing.T) {
+	received := make(chan struct{}, 1)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- struct{}{}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	host, port := hostPort(t, server.URL)
+	exporter, err := OTLPExporter(host, port, WithOTLPCAPEM(caPEM))
+	require.NoError(t, err)
+
+	require.NoError(t, exporter.ExportSpans(context.Background(), testSpans().Snapshots()))
+	require.NoError(t, exporter.Shutdown(context.Background()))
+	<-received
+
+	_, err = OTLPExporter(host, port, WithOTLPCAFile("testdata/missing.pem"))
+	assert.Error(t, err)
+}
+
+type testTraceService struct {
+	collectorTrace.UnimplementedTraceServiceServer
+	received chan metadata.MD
+}
+
+func (s *testTraceService) Export(ctx context.Context, _ *collectorTrace.ExportTraceServiceRequest) (*collectorTrace.ExportTraceServiceResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	s.received <- md
+	return &collectorTrace.ExportTraceServiceResponse{}, nil
+}
+
+func TestOTLPGRPCExporter(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	service := &testTraceService{received: make(chan metadata.MD, 1)}
+	server := grpc.NewServer()
+	collectorTrace.RegisterTraceServiceServer(server, service)
+	go func() { _ = server.Serve(lis) }()
+	t.Cleanup(server.Stop)
+
+	host, port, err := net.SplitHostPort(lis.Addr().String())
+	require.NoError(t, err)
+
+	exporter, err := OTLPGRPCExporter(host, port,
+		WithOTLPHeaders(map[string]string{"x-api-key": "secret"}),
+		WithOTLPCompression(),
+	)
+	require.NoError(t, err)
+
+	require.NoError(t, exporter.ExportSpans(context.Background(), testSpans().Snapshots()))
+	require.NoError(t, exporter.Shutdown(context.Background()))
+
+	md := <-service.received
+	assert.Equal(t, []string{"secret"}, md.Get("x-api-key"))
+}
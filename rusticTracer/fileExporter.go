@@ -0,0 +1,158 @@
+package rusticTracer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+const (
+	defaultFileMaxSize    = 10 << 20 // 10MB
+	defaultFileMaxBackups = 3
+)
+
+// FileExporterConfig different configurations for the FileExporter
+type FileExporterConfig struct {
+	MaxSize    int64 // size in bytes after which the file is rotated
+	MaxBackups int   // number of rotated files to keep, path.1 being the most recent
+}
+
+// FileExporterOption different options to configure the FileExporter
+type FileExporterOption func(config *FileExporterConfig)
+
+// WithFileMaxSize sets the size in bytes after which the file is rotated
+func WithFileMaxSize(size int64) FileExporterOption {
+	return func(config *FileExporterConfig) {
+		config.MaxSize = size
+	}
+}
+
+// WithFileMaxBackups sets the number of rotated files to keep
+func WithFileMaxBackups(n int) FileExporterOption {
+	return func(config *FileExporterConfig) {
+		config.MaxBackups = n
+	}
+}
+
+// FileSpanExporter writes the spans as JSON lines to a file, rotating it once it exceeds the max size
+type FileSpanExporter struct {
+	mu     sync.Mutex
+	path   string
+	config *FileExporterConfig
+	file   *os.File
+	size   int64
+}
+
+// FileExporter creates a JSON lines span exporter appending to the file at path, useful for offline debugging
+func FileExporter(path string, opts ...FileExporterOption) (*FileSpanExporter, error) {
+	config := &FileExporterConfig{MaxSize: defaultFileMaxSize, MaxBackups: defaultFileMaxBackups}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	e := &FileSpanExporter{path: path, config: config}
+	if err := e.open(); err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+// open opens the file at path for appending
+func (e *FileSpanExporter) open() error {
+	file, err := os.OpenFile(e.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open span file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat span file: %w", err)
+	}
+
+	e.file = file
+	e.size = info.Size()
+	return nil
+}
+
+// rotate shifts path.N-1 -> path.N, ..., path -> path.1 dropping the oldest file and reopens path
+func (e *FileSpanExporter) rotate() error {
+	if err := e.file.Close(); err != nil {
+		return fmt.Errorf("failed to close span file: %w", err)
+	}
+
+	if e.config.MaxBackups <= 0 {
+		if err := os.Remove(e.path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove span file: %w", err)
+		}
+		return e.open()
+	}
+
+	for i := e.config.MaxBackups - 1; i > 0; i-- {
+		from := fmt.Sprintf("%s.%d", e.path, i)
+		if err := os.Rename(from, fmt.Sprintf("%s.%d", e.path, i+1)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to rotate span file: %w", err)
+		}
+	}
+	if err := os.Rename(e.path, e.path+".1"); err != nil {
+		return fmt.Errorf("failed to rotate span file: %w", err)
+	}
+
+	return e.open()
+}
+
+// ExportSpans writes each span as a JSON line
+func (e *FileSpanExporter) ExportSpans(ctx context.Context, spans []trace.ReadOnlySpan) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.file == nil {
+		return fmt.Errorf("span file exporter is shut down")
+	}
+
+	for _, span := range spans {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		line, err := json.Marshal(tracetest.SpanStubFromReadOnlySpan(span))
+		if err != nil {
+			return fmt.Errorf("failed to encode span: %w", err)
+		}
+		line = append(line, '\n')
+
+		if e.size > 0 && e.size+int64(len(line)) > e.config.MaxSize {
+			if err := e.rotate(); err != nil {
+				return err
+			}
+		}
+
+		n, err := e.file.Write(line)
+		e.size += int64(n)
+		if err != nil {
+			return fmt.Errorf("failed to write span: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Shutdown closes the file
+func (e *FileSpanExporter) Shutdown(context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.file == nil {
+		return nil
+	}
+
+	err := e.file.Close()
+	e.file = nil
+	return err
+}
@@ -0,0 +1,66 @@
+package rusticTracer
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func readSpanLines(t *testing.T, path string) []tracetest.SpanStub {
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var spans []tracetest.SpanStub
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var span tracetest.SpanStub
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &span))
+		spans = append(spans, span)
+	}
+	require.NoError(t, scanner.Err())
+	return spans
+}
+
+func TestFileExporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spans.jsonl")
+
+	exporter, err := FileExporter(path)
+	require.NoError(t, err)
+
+	spans := tracetest.SpanStubs{{Name: "first"}, {Name: "second"}}
+	require.NoError(t, exporter.ExportSpans(context.Background(), spans.Snapshots()))
+	require.NoError(t, exporter.Shutdown(context.Background()))
+
+	lines := readSpanLines(t, path)
+	require.Len(t, lines, 2)
+	assert.Equal(t, "first", lines[0].Name)
+	assert.Equal(t, "second", lines[1].Name)
+
+	assert.Error(t, exporter.ExportSpans(context.Background(), spans.Snapshots()))
+}
+
+func TestFileExporterRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spans.jsonl")
+
+	exporter, err := FileExporter(path, WithFileMaxSize(1), WithFileMaxBackups(2))
+	require.NoError(t, err)
+
+	for _, name := range []string{"first", "second", "third", "fourth"} {
+		spans := tracetest.SpanStubs{{Name: name}}
+		require.NoError(t, exporter.ExportSpans(context.Background(), spans.Snapshots()))
+	}
+	require.NoError(t, exporter.Shutdown(context.Background()))
+
+	assert.Equal(t, "fourth", readSpanLines(t, path)[0].Name)
+	assert.Equal(t, "third", readSpanLines(t, path+".1")[0].Name)
+	assert.Equal(t, "second", readSpanLines(t, path+".2")[0].Name)
+	assert.NoFileExists(t, path+".3")
+}
@@ -0,0 +1,26 @@
+package rusticTracer
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// GinTracerMiddleware extracts and injects the trace for incoming gin requests to be propagated forward
+func GinTracerMiddleware(service string, opts ...MiddlewareOption) gin.HandlerFunc {
+	st := newServerTracer(service, opts...)
+
+	return func(c *gin.Context) {
+		req, span := st.start(c.Request)
+
+		// Attach the updated context to gin's request
+		c.Request = req
+
+		c.Next()
+
+		var err error
+		if last := c.Errors.Last(); last != nil {
+			err = last.Err
+		}
+
+		st.end(span, c.FullPath(), c.Writer.Status(), err)
+	}
+}
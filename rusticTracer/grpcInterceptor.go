@@ -0,0 +1,261 @@
+package rusticTracer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelCodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.27.0"
+	otelTracer "go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	grpcCodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// metadataCarrier adapts the gRPC metadata to a propagation.TextMapCarrier
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	values := metadata.MD(c).Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// grpcTracer tracing of gRPC calls shared by the client and server interceptors
+type grpcTracer struct {
+	tracer     otelTracer.Tracer
+	propagator propagation.TextMapPropagator
+	duration   metric.Float64Histogram
+	kind       otelTracer.SpanKind
+}
+
+// grpcSpan span of a gRPC call along with what is needed to record its metrics
+type grpcSpan struct {
+	otelTracer.Span
+	ctx   context.Context
+	attrs []attribute.KeyValue
+	start time.Time
+	once  sync.Once
+	done  chan struct{} // closed when the span ends
+}
+
+func newGRPCTracer(service string, kind otelTracer.SpanKind, opts ...MiddlewareOption) *grpcTracer {
+	config := newMiddlewareConfig(opts...)
+
+	name, description := semconv.RPCClientDurationName, semconv.RPCClientDurationDescription
+	if kind == otelTracer.SpanKindServer {
+		name, description = semconv.RPCServerDurationName, semconv.RPCServerDurationDescription
+	}
+
+	// recorded in seconds as the HTTP server durations
+	duration, err := config.MeterProvider.Meter(service).Float64Histogram(name,
+		metric.WithUnit("s"),
+		metric.WithDescription(description),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	return &grpcTracer{
+		tracer:     config.TracerProvider.Tracer(service),
+		propagator: config.Propagator,
+		duration:   duration,
+		kind:       kind,
+	}
+}
+
+// rpcAttributes splits /package.Service/Method into the rpc attributes
+func rpcAttributes(fullMethod string) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{attribute.String("rpc.system", "grpc")}
+
+	service, method, ok := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	if !ok {
+		return attrs
+	}
+
+	return append(attrs, attribute.String("rpc.service", service), attribute.String("rpc.method", method))
+}
+
+// start starts the span named after the full method. Server spans continue the trace extracted from the incoming metadata,
+// client spans inject the trace into the outgoing metadata
+func (g *grpcTracer) start(ctx context.Context, fullMethod string) (context.Context, *grpcSpan) {
+	if g.kind == otelTracer.SpanKindServer {
+		md, _ := metadata.FromIncomingContext(ctx)
+		ctx = g.propagator.Extract(ctx, metadataCarrier(md.Copy()))
+	}
+
+	attrs := rpcAttributes(fullMethod)
+	ctx, span := g.tracer.Start(ctx, strings.TrimPrefix(fullMethod, "/"),
+		otelTracer.WithSpanKind(g.kind),
+		otelTracer.WithAttributes(attrs...),
+	)
+
+	if g.kind == otelTracer.SpanKindClient {
+		md, ok := metadata.FromOutgoingContext(ctx)
+		if ok {
+			md = md.Copy()
+		} else {
+			md = metadata.MD{}
+		}
+		g.propagator.Inject(ctx, metadataCarrier(md))
+		ctx = metadata.NewOutgoingContext(ctx, md)
+	}
+
+	return ctx, &grpcSpan{Span: span, ctx: ctx, attrs: attrs, start: time.Now(), done: make(chan struct{})}
+}
+
+// grpcServerFault reports whether the code is a server fault, mirrors the 5xx classification of the HTTP server spans
+func grpcServerFault(code grpcCodes.Code) bool {
+	switch code {
+	case grpcCodes.Unknown, grpcCodes.DeadlineExceeded, grpcCodes.Unimplemented,
+		grpcCodes.Internal, grpcCodes.Unavailable, grpcCodes.DataLoss:
+		return true
+	default:
+		return false
+	}
+}
+
+// end records the status code and error of the call, ends the span and records the duration, only the first call is effective.
+// Client spans are marked as error for every failed call, server spans only for server faults
+func (g *grpcTracer) end(span *grpcSpan, err error) {
+	span.once.Do(func() {
+		defer close(span.done)
+		defer span.End()
+
+		code := status.Code(err)
+		statusAttr := attribute.Int("rpc.grpc.status_code", int(code))
+		span.SetAttributes(statusAttr)
+
+		if err != nil && (g.kind == otelTracer.SpanKindClient || grpcServerFault(code)) {
+			span.RecordError(err)
+			span.SetStatus(otelCodes.Error, err.Error())
+		}
+
+		if g.duration != nil {
+			g.duration.Record(span.ctx, time.Since(span.start).Seconds(), metric.WithAttributes(append(span.attrs, statusAttr)...))
+		}
+	})
+}
+
+// GRPCUnaryClientInterceptor traces outgoing unary gRPC calls and propagates the trace through the metadata
+func GRPCUnaryClientInterceptor(service string, opts ...MiddlewareOption) grpc.UnaryClientInterceptor {
+	gt := newGRPCTracer(service, otelTracer.SpanKindClient, opts...)
+
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, callOpts ...grpc.CallOption) error {
+		ctx, span := gt.start(ctx, method)
+		err := invoker(ctx, method, req, reply, cc, callOpts...)
+		gt.end(span, err)
+		return err
+	}
+}
+
+// tracedClientStream ends the span once the stream is drained or fails, or once the single response of a client streaming call is received
+type tracedClientStream struct {
+	grpc.ClientStream
+	desc   *grpc.StreamDesc
+	tracer *grpcTracer
+	span   *grpcSpan
+}
+
+func (s *tracedClientStream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+	switch {
+	case errors.Is(err, io.EOF):
+		s.tracer.end(s.span, nil)
+	case err != nil:
+		s.tracer.end(s.span, err)
+	case !s.desc.ServerStreams:
+		s.tracer.end(s.span, nil)
+	}
+	return err
+}
+
+func (s *tracedClientStream) SendMsg(m any) error {
+	err := s.ClientStream.SendMsg(m)
+	if err != nil && !errors.Is(err, io.EOF) {
+		s.tracer.end(s.span, err)
+	}
+	return err
+}
+
+// GRPCStreamClientInterceptor traces outgoing streaming gRPC calls, the span ends when RecvMsg returns io.EOF or an error,
+// after the response of a client streaming call, or when the context of the call is done for abandoned streams
+func GRPCStreamClientInterceptor(service string, opts ...MiddlewareOption) grpc.StreamClientInterceptor {
+	gt := newGRPCTracer(service, otelTracer.SpanKindClient, opts...)
+
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, callOpts ...grpc.CallOption) (grpc.ClientStream, error) {
+		ctx, span := gt.start(ctx, method)
+		stream, err := streamer(ctx, desc, cc, method, callOpts...)
+		if err != nil {
+			gt.end(span, err)
+			return nil, err
+		}
+
+		go func() {
+			select {
+			case <-ctx.Done():
+				gt.end(span, status.FromContextError(ctx.Err()).Err())
+			case <-span.done:
+			}
+		}()
+
+		return &tracedClientStream{ClientStream: stream, desc: desc, tracer: gt, span: span}, nil
+	}
+}
+
+// GRPCUnaryServerInterceptor traces incoming unary gRPC calls continuing the trace propagated through the metadata
+func GRPCUnaryServerInterceptor(service string, opts ...MiddlewareOption) grpc.UnaryServerInterceptor {
+	gt := newGRPCTracer(service, otelTracer.SpanKindServer, opts...)
+
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, span := gt.start(ctx, info.FullMethod)
+		resp, err := handler(ctx, req)
+		gt.end(span, err)
+		return resp, err
+	}
+}
+
+// tracedServerStream carries the span context to the stream handler
+type tracedServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *tracedServerStream) Context() context.Context {
+	return s.ctx
+}
+
+// GRPCStreamServerInterceptor traces incoming streaming gRPC calls continuing the trace propagated through the metadata
+func GRPCStreamServerInterceptor(service string, opts ...MiddlewareOption) grpc.StreamServerInterceptor {
+	gt := newGRPCTracer(service, otelTracer.SpanKindServer, opts...)
+
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, span := gt.start(ss.Context(), info.FullMethod)
+		err := handler(srv, &tracedServerStream{ServerStream: ss, ctx: ctx})
+		gt.end(span, err)
+		return err
+	}
+}
@@ -0,0 +1,193 @@
+package rusticTracer
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	otelCodes "go.opentelemetry.io/otel/codes"
+	otelTracer "go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	grpcCodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func setupGRPC(t *testing.T) (healthpb.HealthClient, *SpanRecorder) {
+	recorder := NewSpanRecorder()
+	tp, propagator := NewTracerProvider("test-service", "test", recorder,
+		WithSyncExport(),
+		WithPropagators(PropagatorB3),
+	)
+	t.Cleanup(func() { _ = tp.Shutdown(context.Background()) })
+	opts := []MiddlewareOption{WithMiddlewareTracerProvider(tp), WithMiddlewarePropagator(propagator)}
+
+	lis := bufconn.Listen(1 << 20)
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(GRPCUnaryServerInterceptor("test-service", opts...)),
+		grpc.StreamInterceptor(GRPCStreamServerInterceptor("test-service", opts...)),
+	)
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("known", healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(server, healthServer)
+	go func() { _ = server.Serve(lis) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(GRPCUnaryClientInterceptor("test-service", opts...)),
+		grpc.WithStreamInterceptor(GRPCStreamClientInterceptor("test-service", opts...)),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return healthpb.NewHealthClient(conn), recorder
+}
+
+func spanAttributes(attrs []attribute.KeyValue) map[attribute.Key]attribute.Value {
+	m := map[attribute.Key]attribute.Value{}
+	for _, kv := range attrs {
+		m[kv.Key] = kv.Value
+	}
+	return m
+}
+
+func TestGRPCUnaryInterceptors(t *testing.T) {
+	testCases := []struct {
+		name               string
+		service            string
+		expectedCode       grpcCodes.Code
+		expectedClientCode otelCodes.Code
+		expectedServerCode otelCodes.Code
+	}{
+		{
+			name:               "successful call",
+			service:            "known",
+			expectedCode:       grpcCodes.OK,
+			expectedClientCode: otelCodes.Unset,
+			expectedServerCode: otelCodes.Unset,
+		},
+		{
+			name:               "client fault is an error only on the client span",
+			service:            "unknown",
+			expectedCode:       grpcCodes.NotFound,
+			expectedClientCode: otelCodes.Error,
+			expectedServerCode: otelCodes.Unset,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client, recorder := setupGRPC(t)
+
+			_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: tc.service})
+			assert.Equal(t, tc.expectedCode, status.Code(err))
+
+			spans := recorder.SpansWithName("grpc.health.v1.Health/Check")
+			require.Len(t, spans, 2)
+			serverSpan, clientSpan := spans[0], spans[1]
+
+			assert.Equal(t, otelTracer.SpanKindServer, serverSpan.SpanKind())
+			assert.Equal(t, otelTracer.SpanKindClient, clientSpan.SpanKind())
+			assert.Equal(t, clientSpan.SpanContext().SpanID(), serverSpan.Parent().SpanID())
+			assert.Equal(t, tc.expectedServerCode, serverSpan.Status().Code)
+			assert.Equal(t, tc.expectedClientCode, clientSpan.Status().Code)
+
+			attrs := spanAttributes(serverSpan.Attributes())
+			assert.Equal(t, "grpc", attrs["rpc.system"].AsString())
+			assert.Equal(t, "grpc.health.v1.Health", attrs["rpc.service"].AsString())
+			assert.Equal(t, "Check", attrs["rpc.method"].AsString())
+			assert.Equal(t, int64(tc.expectedCode), attrs["rpc.grpc.status_code"].AsInt64())
+		})
+	}
+}
+
+func TestGRPCStreamInterceptors(t *testing.T) {
+	client, recorder := setupGRPC(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{Service: "known"})
+	require.NoError(t, err)
+
+	resp, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.Status)
+
+	cancel()
+	_, err = stream.Recv()
+	assert.Equal(t, grpcCodes.Canceled, status.Code(err))
+
+	assert.Eventually(t, func() bool {
+		return len(recorder.SpansWithName("grpc.health.v1.Health/Watch")) == 2
+	}, time.Second, 10*time.Millisecond)
+
+	for _, span := range recorder.SpansWithName("grpc.health.v1.Health/Watch") {
+		if span.SpanKind() == otelTracer.SpanKindServer {
+			// cancellation by the client is not a server fault
+			assert.Equal(t, otelCodes.Unset, span.Status().Code)
+		}
+	}
+}
+
+// fakeClientStream client stream whose messages always succeed
+type fakeClientStream struct {
+	grpc.ClientStream
+}
+
+func (fakeClientStream) SendMsg(any) error { return nil }
+func (fakeClientStream) CloseSend() error  { return nil }
+func (fakeClientStream) RecvMsg(any) error { return nil }
+
+func TestGRPCStreamClientInterceptorEndsSpan(t *testing.T) {
+	recorder := NewSpanRecorder()
+	tp, _ := NewTracerProvider("test-service", "test", recorder, WithSyncExport())
+	t.Cleanup(func() { _ = tp.Shutdown(context.Background()) })
+	interceptor := GRPCStreamClientInterceptor("test-service", WithMiddlewareTracerProvider(tp))
+
+	streamer := func(context.Context, *grpc.StreamDesc, *grpc.ClientConn, string, ...grpc.CallOption) (grpc.ClientStream, error) {
+		return fakeClientStream{}, nil
+	}
+
+	t.Run("client streaming call ends with its response", func(t *testing.T) {
+		desc := &grpc.StreamDesc{StreamName: "Upload", ClientStreams: true}
+		stream, err := interceptor(context.Background(), desc, nil, "/files.Files/Upload", streamer)
+		require.NoError(t, err)
+
+		require.NoError(t, stream.SendMsg("chunk"))
+		require.NoError(t, stream.SendMsg("chunk"))
+		require.NoError(t, stream.CloseSend())
+		require.NoError(t, stream.RecvMsg(nil))
+
+		spans := recorder.SpansWithName("files.Files/Upload")
+		require.Len(t, spans, 1)
+		assert.Equal(t, otelCodes.Unset, spans[0].Status().Code)
+		assert.Equal(t, int64(grpcCodes.OK), spanAttributes(spans[0].Attributes())["rpc.grpc.status_code"].AsInt64())
+	})
+
+	t.Run("abandoned stream ends with its context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		desc := &grpc.StreamDesc{StreamName: "Watch", ServerStreams: true}
+		stream, err := interceptor(ctx, desc, nil, "/files.Files/Watch", streamer)
+		require.NoError(t, err)
+		require.NoError(t, stream.RecvMsg(nil))
+		assert.Empty(t, recorder.SpansWithName("files.Files/Watch"), "server streams end once drained")
+
+		cancel()
+		assert.Eventually(t, func() bool {
+			return len(recorder.SpansWithName("files.Files/Watch")) == 1
+		}, time.Second, 10*time.Millisecond)
+
+		span := recorder.SpansWithName("files.Files/Watch")[0]
+		assert.Equal(t, int64(grpcCodes.Canceled), spanAttributes(span.Attributes())["rpc.grpc.status_code"].AsInt64())
+	})
+}
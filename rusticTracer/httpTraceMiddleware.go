@@ -0,0 +1,23 @@
+package rusticTracer
+
+import (
+	"net/http"
+
+	"github.com/felixge/httpsnoop"
+)
+
+// HTTPTracerMiddleware extracts and injects the trace for incoming net/http requests to be propagated forward
+func HTTPTracerMiddleware(service string, opts ...MiddlewareOption) func(http.Handler) http.Handler {
+	st := newServerTracer(service, opts...)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			req, span := st.start(r)
+
+			m := httpsnoop.CaptureMetrics(next, w, req)
+
+			// net/http has no route template, hence the route is left out
+			st.end(span, "", m.Code, nil)
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package rusticTracer
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	otelTracer "go.opentelemetry.io/otel/trace"
+)
+
+// MiddlewareConfig different configurations for the tracing middlewares
+type MiddlewareConfig struct {
+	TracerProvider otelTracer.TracerProvider
+	Propagator     propagation.TextMapPropagator
+	MeterProvider  metric.MeterProvider
+}
+
+// MiddlewareOption different options to configure the tracing middlewares
+type MiddlewareOption func(config *MiddlewareConfig)
+
+// WithMiddlewareTracerProvider uses tp instead of the global otel tracer provider
+func WithMiddlewareTracerProvider(tp otelTracer.TracerProvider) MiddlewareOption {
+	return func(config *MiddlewareConfig) {
+		config.TracerProvider = tp
+	}
+}
+
+// WithMiddlewarePropagator uses p instead of the global otel propagator
+func WithMiddlewarePropagator(p propagation.TextMapPropagator) MiddlewareOption {
+	return func(config *MiddlewareConfig) {
+		config.Propagator = p
+	}
+}
+
+// WithMiddlewareMeterProvider uses mp instead of the global otel meter provider
+func WithMiddlewareMeterProvider(mp metric.MeterProvider) MiddlewareOption {
+	return func(config *MiddlewareConfig) {
+		config.MeterProvider = mp
+	}
+}
+
+// newMiddlewareConfig applies the options, falling back to the global otel tracer provider, propagator and meter provider
+func newMiddlewareConfig(opts ...MiddlewareOption) *MiddlewareConfig {
+	config := &MiddlewareConfig{}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	if config.TracerProvider == nil {
+		config.TracerProvider = otel.GetTracerProvider()
+	}
+	if config.Propagator == nil {
+		config.Propagator = otel.GetTextMapPropagator()
+	}
+	if config.MeterProvider == nil {
+		config.MeterProvider = otel.GetMeterProvider()
+	}
+
+	return config
+}
@@ -0,0 +1,94 @@
+package rusticTracer
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/contrib/propagators/b3"
+	"go.opentelemetry.io/contrib/propagators/jaeger"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// Propagator identifies a context propagation format, names follow the OTEL_PROPAGATORS convention
+type Propagator string
+
+const (
+	// PropagatorTraceContext W3C traceparent/tracestate headers
+	PropagatorTraceContext Propagator = "tracecontext"
+	// PropagatorBaggage W3C baggage header
+	PropagatorBaggage Propagator = "baggage"
+	// PropagatorB3 Zipkin B3 single header (b3)
+	PropagatorB3 Propagator = "b3"
+	// PropagatorB3Multi Zipkin B3 multi header (X-B3-TraceId, X-B3-SpanId, ...)
+	PropagatorB3Multi Propagator = "b3multi"
+	// PropagatorJaeger Jaeger uber-trace-id header
+	PropagatorJaeger Propagator = "jaeger"
+	// PropagatorNone disables the propagation
+	PropagatorNone Propagator = "none"
+)
+
+// defaultPropagators used when no propagator is configured
+var defaultPropagators = []Propagator{PropagatorTraceContext, PropagatorBaggage}
+
+// textMapPropagator returns the otel propagator for p, nil if p is unknown
+func (p Propagator) textMapPropagator() propagation.TextMapPropagator {
+	switch p {
+	case PropagatorTraceContext:
+		return propagation.TraceContext{}
+	case PropagatorBaggage:
+		return propagation.Baggage{}
+	case PropagatorB3:
+		return b3.New(b3.WithInjectEncoding(b3.B3SingleHeader))
+	case PropagatorB3Multi:
+		return b3.New(b3.WithInjectEncoding(b3.B3MultipleHeader))
+	case PropagatorJaeger:
+		return jaeger.Jaeger{}
+	case PropagatorNone:
+		return propagation.NewCompositeTextMapPropagator()
+	default:
+		return nil
+	}
+}
+
+// NewPropagator builds a composite propagator out of the given formats, unknown formats are reported to the otel error handler
+// and skipped. With no formats, or only unknown ones, it falls back to W3C TraceContext and Baggage
+func NewPropagator(propagators ...Propagator) propagation.TextMapPropagator {
+	textMapPropagators := make([]propagation.TextMapPropagator, 0, len(propagators))
+	for _, p := range propagators {
+		tmp := p.textMapPropagator()
+		if tmp == nil {
+			otel.Handle(fmt.Errorf("unknown propagator %q", p))
+			continue
+		}
+		textMapPropagators = append(textMapPropagators, tmp)
+	}
+
+	if len(textMapPropagators) == 0 {
+		for _, p := range defaultPropagators {
+			textMapPropagators = append(textMapPropagators, p.textMapPropagator())
+		}
+	}
+
+	return propagation.NewCompositeTextMapPropagator(textMapPropagators...)
+}
+
+// propagatorsFromEnv reads the propagators from OTEL_PROPAGATORS, "none" disables propagation
+func propagatorsFromEnv() []Propagator {
+	value := strings.TrimSpace(os.Getenv("OTEL_PROPAGATORS"))
+	if value == "" {
+		return nil
+	}
+
+	var propagators []Propagator
+	for _, name := range strings.Split(value, ",") {
+		p := Propagator(strings.TrimSpace(strings.ToLower(name)))
+		if p == PropagatorNone {
+			return []Propagator{PropagatorNone}
+		}
+		propagators = append(propagators, p)
+	}
+
+	return propagators
+}
@@ -0,0 +1,111 @@
+package rusticTracer
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/propagation"
+	otelTracer "go.opentelemetry.io/otel/trace"
+)
+
+func testSpanContext(t *testing.T) otelTracer.SpanContext {
+	traceID, err := otelTracer.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	require.NoError(t, err)
+	spanID, err := otelTracer.SpanIDFromHex("00f067aa0ba902b7")
+	require.NoError(t, err)
+
+	return otelTracer.NewSpanContext(otelTracer.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: otelTracer.FlagsSampled,
+		Remote:     true,
+	})
+}
+
+func TestNewPropagator(t *testing.T) {
+	testCases := []struct {
+		name            string
+		propagators     []Propagator
+		expectedHeaders []string
+	}{
+		{
+			name:            "defaults to trace context and baggage",
+			expectedHeaders: []string{"Traceparent", "Baggage"},
+		},
+		{
+			name:            "b3 single header",
+			propagators:     []Propagator{PropagatorB3},
+			expectedHeaders: []string{"B3"},
+		},
+		{
+			name:            "b3 multi header",
+			propagators:     []Propagator{PropagatorB3Multi},
+			expectedHeaders: []string{"X-B3-Traceid", "X-B3-Spanid", "X-B3-Sampled"},
+		},
+		{
+			name:            "jaeger",
+			propagators:     []Propagator{PropagatorJaeger},
+			expectedHeaders: []string{"Uber-Trace-Id"},
+		},
+		{
+			name:            "unknown propagators are skipped",
+			propagators:     []Propagator{"unknown", PropagatorTraceContext},
+			expectedHeaders: []string{"Traceparent"},
+		},
+		{
+			name:            "only unknown propagators fall back to the defaults",
+			propagators:     []Propagator{"tracecontxt"},
+			expectedHeaders: []string{"Traceparent", "Baggage"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			member, err := baggage.NewMember("tenant", "acme")
+			require.NoError(t, err)
+			bag, err := baggage.New(member)
+			require.NoError(t, err)
+
+			ctx := otelTracer.ContextWithRemoteSpanContext(context.Background(), testSpanContext(t))
+			ctx = baggage.ContextWithBaggage(ctx, bag)
+
+			p := NewPropagator(tc.propagators...)
+			header := http.Header{}
+			p.Inject(ctx, propagation.HeaderCarrier(header))
+
+			for _, h := range tc.expectedHeaders {
+				assert.NotEmpty(t, header.Get(h), h)
+			}
+
+			extracted := p.Extract(context.Background(), propagation.HeaderCarrier(header))
+			sc := otelTracer.SpanContextFromContext(extracted)
+			assert.Equal(t, testSpanContext(t).TraceID(), sc.TraceID())
+		})
+	}
+}
+
+// errorHandler collects the errors reported to otel.Handle
+type errorHandler struct {
+	errs []error
+}
+
+func (h *errorHandler) Handle(err error) {
+	h.errs = append(h.errs, err)
+}
+
+func TestNewPropagatorReportsUnknownPropagators(t *testing.T) {
+	handler := &errorHandler{}
+	previous := otel.GetErrorHandler()
+	otel.SetErrorHandler(handler)
+	t.Cleanup(func() { otel.SetErrorHandler(previous) })
+
+	NewPropagator(PropagatorB3, "jeager")
+
+	require.Len(t, handler.errs, 1)
+	assert.EqualError(t, handler.errs[0], `unknown propagator "jeager"`)
+}
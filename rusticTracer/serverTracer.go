@@ -0,0 +1,96 @@
+package rusticTracer
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	otelTracer "go.opentelemetry.io/otel/trace"
+)
+
+// serverSpanName span name of every incoming HTTP request irrespective of the framework
+const serverSpanName = "http.request"
+
+// serverTracer framework neutral tracing of incoming HTTP requests shared by all the middlewares
+type serverTracer struct {
+	tracer     otelTracer.Tracer
+	propagator propagation.TextMapPropagator
+	duration   metric.Float64Histogram
+}
+
+// serverSpan span of an incoming HTTP request along with what is needed to record its metrics
+type serverSpan struct {
+	otelTracer.Span
+	ctx    context.Context
+	method string
+	start  time.Time
+}
+
+func newServerTracer(service string, opts ...MiddlewareOption) *serverTracer {
+	config := newMiddlewareConfig(opts...)
+
+	duration, err := config.MeterProvider.Meter(service).Float64Histogram("http.server.request.duration",
+		metric.WithUnit("s"),
+		metric.WithDescription("Duration of HTTP server requests"),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	return &serverTracer{
+		tracer:     config.TracerProvider.Tracer(service),
+		propagator: config.Propagator,
+		duration:   duration,
+	}
+}
+
+// start extracts the trace from the incoming request, starts the server span and returns the request carrying the span context
+func (s *serverTracer) start(r *http.Request) (*http.Request, *serverSpan) {
+	// Extract the context from incoming request headers
+	ctx := s.propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+	ctx, span := s.tracer.Start(ctx, serverSpanName, otelTracer.WithSpanKind(otelTracer.SpanKindServer))
+
+	span.SetAttributes(
+		attribute.String("http.method", r.Method),
+		attribute.String("http.url", r.URL.String()),
+	)
+
+	// Inject updated trace context into request headers for downstream services
+	s.propagator.Inject(ctx, propagation.HeaderCarrier(r.Header))
+
+	return r.WithContext(ctx), &serverSpan{Span: span, ctx: ctx, method: r.Method, start: time.Now()}
+}
+
+// end records the route, status code and error of the request, ends the span and records the duration.
+// route is the framework's route template, left out when unknown
+func (s *serverTracer) end(span *serverSpan, route string, statusCode int, err error) {
+	defer span.End()
+
+	attrs := []attribute.KeyValue{attribute.String("http.method", span.method)}
+	if route != "" {
+		span.SetAttributes(attribute.String("resource.name", route))
+		attrs = append(attrs, attribute.String("http.route", route))
+	}
+	if statusCode != 0 {
+		span.SetAttributes(attribute.Int("http.status_code", statusCode))
+		attrs = append(attrs, attribute.Int("http.status_code", statusCode))
+	}
+
+	switch {
+	case err != nil:
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	case statusCode >= http.StatusInternalServerError:
+		span.SetStatus(codes.Error, http.StatusText(statusCode))
+	}
+
+	if s.duration != nil {
+		s.duration.Record(span.ctx, time.Since(span.start).Seconds(), metric.WithAttributes(attrs...))
+	}
+}
@@ -0,0 +1,129 @@
+package rusticTracer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-chi/chi/v5"
+	echov3 "github.com/labstack/echo"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	otelTracer "go.opentelemetry.io/otel/trace"
+)
+
+func TestServerTracerMiddlewares(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	testCases := []struct {
+		name          string
+		route         string
+		expectedRoute string
+		handler       func(opts ...MiddlewareOption) http.Handler
+	}{
+		{
+			name:  "net/http",
+			route: "/users/1",
+			handler: func(opts ...MiddlewareOption) http.Handler {
+				mux := http.NewServeMux()
+				mux.HandleFunc("/users/", func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusTeapot)
+				})
+				return HTTPTracerMiddleware("test-service", opts...)(mux)
+			},
+		},
+		{
+			name:          "chi",
+			route:         "/users/1",
+			expectedRoute: "/users/{id}",
+			handler: func(opts ...MiddlewareOption) http.Handler {
+				r := chi.NewRouter()
+				r.Use(ChiTracerMiddleware("test-service", opts...))
+				r.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusTeapot)
+				})
+				return r
+			},
+		},
+		{
+			name:          "gin",
+			route:         "/users/1",
+			expectedRoute: "/users/:id",
+			handler: func(opts ...MiddlewareOption) http.Handler {
+				r := gin.New()
+				r.Use(GinTracerMiddleware("test-service", opts...))
+				r.GET("/users/:id", func(c *gin.Context) {
+					c.Status(http.StatusTeapot)
+				})
+				return r
+			},
+		},
+		{
+			name:          "echo v4",
+			route:         "/users/1",
+			expectedRoute: "/users/:id",
+			handler: func(opts ...MiddlewareOption) http.Handler {
+				e := echo.New()
+				e.Use(Echov4TracerMiddleware("test-service", opts...))
+				e.GET("/users/:id", func(c echo.Context) error {
+					return c.NoContent(http.StatusTeapot)
+				})
+				return e
+			},
+		},
+		{
+			name:          "echo v3",
+			route:         "/users/1",
+			expectedRoute: "/users/:id",
+			handler: func(opts ...MiddlewareOption) http.Handler {
+				e := echov3.New()
+				e.Use(Echov3TracerMiddleware("test-service", opts...))
+				e.GET("/users/:id", func(c echov3.Context) error {
+					return c.NoContent(http.StatusTeapot)
+				})
+				return e
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := NewSpanRecorder()
+			tp, propagator := NewTracerProvider("test-service", "test", recorder, WithSyncExport())
+			t.Cleanup(func() { _ = tp.Shutdown(context.Background()) })
+
+			handler := tc.handler(WithMiddlewareTracerProvider(tp), WithMiddlewarePropagator(propagator))
+
+			req := httptest.NewRequest(http.MethodGet, tc.route, nil)
+			propagator.Inject(otelTracer.ContextWithRemoteSpanContext(context.Background(), testSpanContext(t)), propagation.HeaderCarrier(req.Header))
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusTeapot, w.Code)
+
+			spans := recorder.SpansWithName(serverSpanName)
+			require.Len(t, spans, 1)
+			span := spans[0]
+
+			assert.Equal(t, otelTracer.SpanKindServer, span.SpanKind())
+			assert.Equal(t, testSpanContext(t).TraceID(), span.SpanContext().TraceID())
+			assert.Equal(t, testSpanContext(t).SpanID(), span.Parent().SpanID())
+			assert.Equal(t, codes.Unset, span.Status().Code)
+
+			attrs := map[attribute.Key]attribute.Value{}
+			for _, kv := range span.Attributes() {
+				attrs[kv.Key] = kv.Value
+			}
+			assert.Equal(t, http.MethodGet, attrs["http.method"].AsString())
+			assert.Equal(t, tc.route, attrs["http.url"].AsString())
+			assert.Equal(t, int64(http.StatusTeapot), attrs["http.status_code"].AsInt64())
+			assert.Equal(t, tc.expectedRoute, attrs["resource.name"].AsString())
+		})
+	}
+}
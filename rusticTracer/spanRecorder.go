@@ -0,0 +1,84 @@
+package rusticTracer
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/otel/sdk/trace"
+)
+
+// SpanRecorder in-memory span exporter to assert tracing behaviour in tests, spans are retained after shutdown.
+// Use it along with WithSyncExport so that the spans are recorded as soon as they end
+type SpanRecorder struct {
+	mu    sync.RWMutex
+	spans []trace.ReadOnlySpan
+}
+
+// NewSpanRecorder creates an empty SpanRecorder
+func NewSpanRecorder() *SpanRecorder {
+	return &SpanRecorder{}
+}
+
+// ExportSpans records the ended spans
+func (r *SpanRecorder) ExportSpans(_ context.Context, spans []trace.ReadOnlySpan) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = append(r.spans, spans...)
+	return nil
+}
+
+// Shutdown is a no-op, the recorded spans are kept for assertions
+func (r *SpanRecorder) Shutdown(context.Context) error {
+	return nil
+}
+
+// Spans returns all the recorded spans in the order they ended
+func (r *SpanRecorder) Spans() []trace.ReadOnlySpan {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	spans := make([]trace.ReadOnlySpan, len(r.spans))
+	copy(spans, r.spans)
+	return spans
+}
+
+// Filter returns the recorded spans matching fn
+func (r *SpanRecorder) Filter(fn func(span trace.ReadOnlySpan) bool) []trace.ReadOnlySpan {
+	var spans []trace.ReadOnlySpan
+	for _, span := range r.Spans() {
+		if fn(span) {
+			spans = append(spans, span)
+		}
+	}
+	return spans
+}
+
+// SpansWithName returns the recorded spans with the given name
+func (r *SpanRecorder) SpansWithName(name string) []trace.ReadOnlySpan {
+	return r.Filter(func(span trace.ReadOnlySpan) bool {
+		return span.Name() == name
+	})
+}
+
+// SpanWithName returns the first recorded span with the given name
+func (r *SpanRecorder) SpanWithName(name string) (trace.ReadOnlySpan, bool) {
+	spans := r.SpansWithName(name)
+	if len(spans) == 0 {
+		return nil, false
+	}
+	return spans[0], true
+}
+
+// ChildrenOf returns the recorded spans whose parent is the given span
+func (r *SpanRecorder) ChildrenOf(parent trace.ReadOnlySpan) []trace.ReadOnlySpan {
+	return r.Filter(func(span trace.ReadOnlySpan) bool {
+		return span.Parent().SpanID() == parent.SpanContext().SpanID() &&
+			span.Parent().TraceID() == parent.SpanContext().TraceID()
+	})
+}
+
+// Reset clears the recorded spans
+func (r *SpanRecorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = nil
+}
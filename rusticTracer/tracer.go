@@ -3,12 +3,9 @@ package rusticTracer
 import (
 	"context"
 	"fmt"
-	"log"
 
 	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
-	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
-	stdoutTrace "go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/resource"
 	"go.opentelemetry.io/otel/sdk/trace"
@@ -16,47 +13,134 @@ import (
 	otelTracer "go.opentelemetry.io/otel/trace"
 )
 
-// StdOutExporter outputs the traces to the stdout
-func StdOutExporter() *stdoutTrace.Exporter {
-	// Currently using stdOut exporter
-	stdOutExporter, err := stdoutTrace.New(stdoutTrace.WithPrettyPrint())
-	if err != nil {
-		log.Fatalf("failed to create exporter: %v", err)
+// TracerConfig different configurations for InitTracer
+type TracerConfig struct {
+	Propagators        []Propagator
+	Sampler            trace.Sampler
+	ResourceAttributes []attribute.KeyValue
+	ResourceOptions    []resource.Option
+	BatchOptions       []trace.BatchSpanProcessorOption
+	SyncExport         bool
+}
+
+// TracerOption different options to configure InitTracer
+type TracerOption func(config *TracerConfig)
+
+// WithPropagators sets the propagation formats used to extract and inject the trace context,
+// they are combined into a single composite propagator in the given order.
+// If not set, OTEL_PROPAGATORS is respected
+func WithPropagators(p ...Propagator) TracerOption {
+	return func(config *TracerConfig) {
+		config.Propagators = p
 	}
+}
 
-	return stdOutExporter
+// WithSampler sets the sampler of the tracer provider. If not set, OTEL_TRACES_SAMPLER and OTEL_TRACES_SAMPLER_ARG are respected
+func WithSampler(s trace.Sampler) TracerOption {
+	return func(config *TracerConfig) {
+		config.Sampler = s
+	}
 }
 
-// OTLPExporter Uses OpenTelemetry’s standard OTLP/gRPC or HTTP with host/port
-func OTLPExporter(host, port string) *otlptrace.Exporter {
-	// Create an OTLP exporter (send data to OpenTelemetry collector)
-	oltpExporter, err := otlptracehttp.New(context.Background(), otlptracehttp.WithInsecure(), otlptracehttp.WithEndpoint(fmt.Sprintf("%s:%s", host, port)))
-	if err != nil {
-		log.Fatalf("failed to create exporter: %v", err)
+// WithParentBasedRatioSampler samples the given ratio of root spans and follows the parent's decision otherwise
+func WithParentBasedRatioSampler(ratio float64) TracerOption {
+	return WithSampler(trace.ParentBased(trace.TraceIDRatioBased(ratio)))
+}
+
+// WithResourceAttributes adds extra resource attributes such as version, region or pod name,
+// they take precedence over OTEL_RESOURCE_ATTRIBUTES
+func WithResourceAttributes(attrs ...attribute.KeyValue) TracerOption {
+	return func(config *TracerConfig) {
+		config.ResourceAttributes = append(config.ResourceAttributes, attrs...)
 	}
+}
+
+// WithResourceOptions adds resource detectors such as resource.WithHost(), resource.WithContainer() or resource.WithDetectors(...)
+func WithResourceOptions(opts ...resource.Option) TracerOption {
+	return func(config *TracerConfig) {
+		config.ResourceOptions = append(config.ResourceOptions, opts...)
+	}
+}
 
-	return oltpExporter
+// WithBatchOptions tunes the batch span processor, e.g. trace.WithMaxExportBatchSize, trace.WithMaxQueueSize, trace.WithBatchTimeout.
+// If not set, OTEL_BSP_* are respected
+func WithBatchOptions(opts ...trace.BatchSpanProcessorOption) TracerOption {
+	return func(config *TracerConfig) {
+		config.BatchOptions = append(config.BatchOptions, opts...)
+	}
+}
+
+// WithSyncExport exports every span synchronously as it ends instead of batching, meant for tests and debugging
+func WithSyncExport() TracerOption {
+	return func(config *TracerConfig) {
+		config.SyncExport = true
+	}
 }
 
-// InitTracer initialises the otel tracer for a serviceName and env with exporter of choice
-func InitTracer(serviceName, env string, exporter trace.SpanExporter) func() {
-	tp := trace.NewTracerProvider(
-		trace.WithBatcher(exporter),
-		trace.WithResource(resource.NewWithAttributes(
-			semconv.SchemaURL,
+// newResource builds the resource for serviceName and env, OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES override them
+// while the configured resource attributes override the environment
+func newResource(serviceName, env string, config *TracerConfig) *resource.Resource {
+	opts := []resource.Option{
+		resource.WithTelemetrySDK(),
+		resource.WithAttributes(
 			semconv.ServiceNameKey.String(serviceName),
 			semconv.DeploymentEnvironmentNameKey.String(env),
-		)),
-	)
+		),
+		resource.WithFromEnv(),
+	}
+	opts = append(opts, config.ResourceOptions...)
+	opts = append(opts, resource.WithAttributes(config.ResourceAttributes...))
+
+	res, err := resource.New(context.Background(), opts...)
+	if err != nil {
+		// partial resources are still usable, hence only report the error
+		otel.Handle(err)
+	}
+
+	return res
+}
+
+// NewTracerProvider builds the tracer provider and propagator for a serviceName and env with exporter of choice
+// without touching the otel globals, to be injected in the HTTPClient and the middlewares
+func NewTracerProvider(serviceName, env string, exporter trace.SpanExporter, opts ...TracerOption) (*trace.TracerProvider, propagation.TextMapPropagator) {
+	config := &TracerConfig{}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	tpOpts := []trace.TracerProviderOption{trace.WithResource(newResource(serviceName, env, config))}
+	if config.SyncExport {
+		tpOpts = append(tpOpts, trace.WithSyncer(exporter))
+	} else {
+		tpOpts = append(tpOpts, trace.WithBatcher(exporter, config.BatchOptions...))
+	}
+	if config.Sampler != nil {
+		tpOpts = append(tpOpts, trace.WithSampler(config.Sampler))
+	}
+
+	propagators := config.Propagators
+	if len(propagators) == 0 {
+		propagators = propagatorsFromEnv()
+	}
+
+	return trace.NewTracerProvider(tpOpts...), NewPropagator(propagators...)
+}
+
+// InitTracer initialises the otel tracer for a serviceName and env with exporter of choice.
+// The tracer provider and propagator are set globally, hence used by the middlewares and the HTTPClient unless they are given explicit ones.
+// The returned function shuts down the tracer provider flushing the pending spans
+func InitTracer(serviceName, env string, exporter trace.SpanExporter, opts ...TracerOption) func() error {
+	tp, propagator := NewTracerProvider(serviceName, env, exporter, opts...)
 
 	otel.SetTracerProvider(tp)
-	otel.SetTextMapPropagator(propagation.TraceContext{})
+	otel.SetTextMapPropagator(propagator)
 
 	// Return function to shut down the tracer
-	return func() {
+	return func() error {
 		if err := tp.Shutdown(context.Background()); err != nil {
-			log.Fatalf("failed to shutdown tracer: %v", err)
+			return fmt.Errorf("failed to shutdown tracer: %w", err)
 		}
+		return nil
 	}
 }
 
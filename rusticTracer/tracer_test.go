@@ -0,0 +1,68 @@
+package rusticTracer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestInitTracer(t *testing.T) {
+	t.Setenv("OTEL_RESOURCE_ATTRIBUTES", "region=eu-west-1,version=from-env")
+	t.Setenv("OTEL_PROPAGATORS", "b3multi")
+
+	recorder := NewSpanRecorder()
+	shutdown := InitTracer("test-service", "test", recorder,
+		WithResourceAttributes(attribute.String("version", "1.2.3")),
+		WithSampler(trace.AlwaysSample()),
+	)
+
+	_, span := GetTracer("test-service").Start(context.Background(), "test-span")
+	span.End()
+
+	require.NoError(t, shutdown())
+
+	recorded, ok := recorder.SpanWithName("test-span")
+	require.True(t, ok)
+
+	attrs := map[attribute.Key]string{}
+	for _, kv := range recorded.Resource().Attributes() {
+		attrs[kv.Key] = kv.Value.Emit()
+	}
+	assert.Equal(t, "test-service", attrs["service.name"])
+	assert.Equal(t, "test", attrs["deployment.environment.name"])
+	assert.Equal(t, "eu-west-1", attrs["region"])
+	assert.Equal(t, "1.2.3", attrs["version"])
+
+	assert.Equal(t, []Propagator{PropagatorB3Multi}, propagatorsFromEnv())
+}
+
+func TestSpanRecorder(t *testing.T) {
+	recorder := NewSpanRecorder()
+	shutdown := InitTracer("test-service", "test", recorder, WithSyncExport())
+	t.Cleanup(func() { _ = shutdown() })
+
+	tr := GetTracer("test-service")
+	ctx, parent := tr.Start(context.Background(), "parent")
+	_, child := tr.Start(ctx, "child")
+	child.End()
+	_, sibling := tr.Start(ctx, "child")
+	sibling.End()
+	parent.End()
+
+	require.Len(t, recorder.Spans(), 3)
+	assert.Len(t, recorder.SpansWithName("child"), 2)
+
+	p, ok := recorder.SpanWithName("parent")
+	require.True(t, ok)
+	assert.Len(t, recorder.ChildrenOf(p), 2)
+
+	_, ok = recorder.SpanWithName("missing")
+	assert.False(t, ok)
+
+	recorder.Reset()
+	assert.Empty(t, recorder.Spans())
+}
@@ -0,0 +1,36 @@
+package rustic
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Service a base URL scoped client holding the default HTTPConfigOptions of the calls made through it
+type Service struct {
+	BaseURL  string
+	Defaults []HTTPConfigOptions
+}
+
+// NewService creates a Service for baseURL with default options, e.g. WithHttpClient, WithTimeout, WithCircuitBreaker, WithHeaders.
+// The defaults only apply to the calls given Service.Options
+func NewService(baseURL string, defaults ...HTTPConfigOptions) *Service {
+	return &Service{BaseURL: strings.TrimSuffix(baseURL, "/"), Defaults: defaults}
+}
+
+// Path returns the absolute URL of the path relative to the base URL, format and args as in fmt.Sprintf
+func (s *Service) Path(format string, args ...any) string {
+	path := format
+	if len(args) != 0 {
+		path = fmt.Sprintf(format, args...)
+	}
+	if path == "" {
+		return s.BaseURL
+	}
+	return s.BaseURL + "/" + strings.TrimPrefix(path, "/")
+}
+
+// Options returns the defaults of the service followed by the per-call options, hence per-call options override the defaults
+// and headers are merged per key
+func (s *Service) Options(opts ...HTTPConfigOptions) []HTTPConfigOptions {
+	return append(append(make([]HTTPConfigOptions, 0, len(s.Defaults)+len(opts)), s.Defaults...), opts...)
+}